@@ -0,0 +1,214 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/joho/godotenv"
+)
+
+// Minimum accepted RSA key size in bits
+const minRSAKeyBits = 2048
+
+// JWTKey is a signing or verification key identified by its kid
+type JWTKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer // nil for verification-only keys
+	PublicKey  crypto.PublicKey
+}
+
+// JWTKeySet holds the active signing key and every key tokens may still be
+// verified with
+type JWTKeySet struct {
+	Active *JWTKey
+	keys   map[string]*JWTKey
+}
+
+// JWK is the public part of a key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+var JWTKeys *JWTKeySet
+
+// InitJWTKeys loads the JWT keys from JWT_KEYS_DIR (default "keys") and
+// selects the signing key named by JWT_ACTIVE_KID. The server refuses to
+// start without a usable signing key.
+func InitJWTKeys() {
+	err := godotenv.Load()
+	if err != nil {
+		log.Fatal("Error while loading .env file", err)
+	}
+
+	dir := os.Getenv("JWT_KEYS_DIR")
+	if dir == "" {
+		dir = "keys"
+	}
+
+	JWTKeys, err = LoadJWTKeys(dir, os.Getenv("JWT_ACTIVE_KID"))
+	if err != nil {
+		log.Fatal("Failed to load JWT keys: ", err)
+	}
+
+	log.Printf("Loaded %d JWT keys, signing with '%s' (%s)", len(JWTKeys.keys), JWTKeys.Active.ID, JWTKeys.Active.Method.Alg())
+}
+
+// LoadJWTKeys reads every *.pem file in dir. The file name without extension
+// is the kid. Private keys (PKCS#8 RSA/Ed25519 or PKCS#1 RSA) can sign and
+// verify, public keys (PKIX, conventionally named <kid>.pub.pem) only verify.
+// If activeKID is empty there must be exactly one private key.
+func LoadJWTKeys(dir, activeKID string) (*JWTKeySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+
+	set := &JWTKeySet{keys: map[string]*JWTKey{}}
+	var signingKeys []*JWTKey
+
+	for _, file := range files {
+		kid := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".pem"), ".pub")
+
+		key, err := parseJWTKey(kid, file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		if existing, ok := set.keys[kid]; ok {
+			// A private key wins over its own public counterpart
+			if existing.PrivateKey != nil || key.PrivateKey == nil {
+				continue
+			}
+		}
+		set.keys[kid] = key
+	}
+
+	for _, key := range set.keys {
+		if key.PrivateKey != nil {
+			signingKeys = append(signingKeys, key)
+		}
+	}
+
+	if len(signingKeys) == 0 {
+		return nil, fmt.Errorf("no private key found in %s", dir)
+	}
+
+	if activeKID == "" {
+		if len(signingKeys) > 1 {
+			return nil, fmt.Errorf("multiple private keys found in %s, set JWT_ACTIVE_KID", dir)
+		}
+		set.Active = signingKeys[0]
+		return set, nil
+	}
+
+	active, ok := set.keys[activeKID]
+	if !ok || active.PrivateKey == nil {
+		return nil, fmt.Errorf("no private key with kid '%s' in %s", activeKID, dir)
+	}
+	set.Active = active
+
+	return set, nil
+}
+
+func parseJWTKey(kid, file string) (*JWTKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &JWTKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.PublicKey = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T, use RSA or Ed25519", parsed)
+	}
+
+	if pub, ok := key.PublicKey.(*rsa.PublicKey); ok && pub.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("RSA key must be at least %d bits", minRSAKeyBits)
+	}
+
+	return key, nil
+}
+
+// Lookup returns the verification key with the given kid
+func (s *JWTKeySet) Lookup(kid string) (*JWTKey, bool) {
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// JWKS returns the public keys of the set, ordered by kid
+func (s *JWTKeySet) JWKS() []JWK {
+	kids := make([]string, 0, len(s.keys))
+	for kid := range s.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := make([]JWK, 0, len(kids))
+	for _, kid := range kids {
+		key := s.keys[kid]
+		jwk := JWK{Kid: kid, Use: "sig", Alg: key.Method.Alg()}
+
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+
+		jwks = append(jwks, jwk)
+	}
+
+	return jwks
+}
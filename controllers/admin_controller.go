@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"time"
+
+	"property_lister/models"
+	"property_lister/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AdminResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=admin agent owner"`
+}
+
+// UpdateUserRole handles PATCH /api/admin/users/:id/role
+func UpdateUserRole(c *fiber.Ctx) error {
+	objID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(AdminResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	var req UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(AdminResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+	}
+
+	if !models.IsValidRole(req.Role) {
+		return c.Status(400).JSON(AdminResponse{
+			Success: false,
+			Message: "Role must be one of admin, agent or owner",
+		})
+	}
+
+	var user models.User
+	err = mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": objID}).Decode(&user)
+	if err != nil {
+		return c.Status(404).JSON(AdminResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	user.Role = req.Role
+	user.UpdatedAt = time.Now()
+	_, err = mgm.Coll(&user).UpdateOne(mgm.Ctx(), bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{"role": user.Role, "updated_at": user.UpdatedAt},
+	})
+	if err != nil {
+		return c.Status(500).JSON(AdminResponse{
+			Success: false,
+			Message: "Failed to update role",
+		})
+	}
+
+	// Existing tokens carry the old role, force the user to log in again
+	services.RevokeAllUserTokens(user.ID.Hex())
+	services.DeleteCache(services.GetCacheKey("user_profile", user.ID.Hex(), ""))
+
+	return c.JSON(AdminResponse{
+		Success: true,
+		Message: "Role updated successfully",
+		Data:    toUserResponse(&user),
+	})
+}
+
+// DeleteUser handles DELETE /api/admin/users/:id and erases the account
+// together with everything tied to it
+func DeleteUser(c *fiber.Ctx) error {
+	objID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(AdminResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	var user models.User
+	err = mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": objID}).Decode(&user)
+	if err != nil {
+		return c.Status(404).JSON(AdminResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	if err := services.EraseUser(&user); err != nil {
+		return c.Status(500).JSON(AdminResponse{
+			Success: false,
+			Message: "Failed to delete user",
+		})
+	}
+
+	return c.JSON(AdminResponse{
+		Success: true,
+		Message: "User deleted successfully",
+	})
+}
+
+// UnlockUser handles POST /api/admin/users/:id/unlock and clears any login
+// lockout on the account
+func UnlockUser(c *fiber.Ctx) error {
+	objID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(AdminResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	var user models.User
+	err = mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": objID}).Decode(&user)
+	if err != nil {
+		return c.Status(404).JSON(AdminResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	if err := services.UnlockAccount(user.Email); err != nil {
+		return c.Status(500).JSON(AdminResponse{
+			Success: false,
+			Message: "Failed to unlock account",
+		})
+	}
+
+	return c.JSON(AdminResponse{
+		Success: true,
+		Message: "Account unlocked successfully",
+	})
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"time"
+
+	"property_lister/models"
+	"property_lister/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Maximum number of active API keys per user
+const maxAPIKeysPerUser = 20
+
+type APIKeyResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required"`
+	Scopes        []string `json:"scopes" validate:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreatedAPIKeyData is returned once on creation, the only time the full key is visible
+type CreatedAPIKeyData struct {
+	*models.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKey handles POST /api/api-keys
+func CreateAPIKey(c *fiber.Ctx) error {
+	var req CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.Name == "" || len(req.Scopes) == 0 {
+		return c.Status(400).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Name and at least one scope are required",
+		})
+	}
+
+	for _, scope := range req.Scopes {
+		if !models.IsValidAPIKeyScope(scope) {
+			return c.Status(400).JSON(APIKeyResponse{
+				Success: false,
+				Message: "Unknown scope: " + scope,
+			})
+		}
+	}
+
+	if req.ExpiresInDays < 0 {
+		return c.Status(400).JSON(APIKeyResponse{
+			Success: false,
+			Message: "expires_in_days cannot be negative",
+		})
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(400).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	active, err := mgm.Coll(&models.APIKey{}).CountDocuments(mgm.Ctx(), bson.M{
+		"user_id":    userObjID,
+		"revoked_at": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return c.Status(500).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Failed to count API keys",
+		})
+	}
+	if active >= maxAPIKeysPerUser {
+		return c.Status(400).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Maximum number of active API keys reached, revoke one first",
+		})
+	}
+
+	key, prefix, keyHash, err := services.GenerateAPIKey()
+	if err != nil {
+		return c.Status(500).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Failed to generate API key",
+		})
+	}
+
+	now := time.Now()
+	apiKey := &models.APIKey{
+		UserID:    userObjID,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   keyHash,
+		Scopes:    req.Scopes,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, req.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := mgm.Coll(apiKey).Create(apiKey); err != nil {
+		return c.Status(500).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Failed to create API key",
+		})
+	}
+
+	return c.Status(201).JSON(APIKeyResponse{
+		Success: true,
+		Message: "API key created. Copy it now, it won't be shown again",
+		Data: CreatedAPIKeyData{
+			APIKey: apiKey,
+			Key:    key,
+		},
+	})
+}
+
+// GetAPIKeys handles GET /api/api-keys
+func GetAPIKeys(c *fiber.Ctx) error {
+	userObjID, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(400).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	apiKeys := []models.APIKey{}
+	cursor, err := mgm.Coll(&models.APIKey{}).Find(mgm.Ctx(), bson.M{
+		"user_id": userObjID,
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return c.Status(500).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Failed to fetch API keys",
+		})
+	}
+	defer cursor.Close(mgm.Ctx())
+
+	if err = cursor.All(mgm.Ctx(), &apiKeys); err != nil {
+		return c.Status(500).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Failed to decode API keys",
+		})
+	}
+
+	return c.JSON(APIKeyResponse{
+		Success: true,
+		Data:    apiKeys,
+	})
+}
+
+// RevokeAPIKey handles DELETE /api/api-keys/:id
+func RevokeAPIKey(c *fiber.Ctx) error {
+	keyObjID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Invalid API key ID",
+		})
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(400).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	now := time.Now()
+	result, err := mgm.Coll(&models.APIKey{}).UpdateOne(mgm.Ctx(), bson.M{
+		"_id":        keyObjID,
+		"user_id":    userObjID,
+		"revoked_at": bson.M{"$exists": false},
+	}, bson.M{
+		"$set": bson.M{"revoked_at": now, "updated_at": now},
+	})
+	if err != nil {
+		return c.Status(500).JSON(APIKeyResponse{
+			Success: false,
+			Message: "Failed to revoke API key",
+		})
+	}
+
+	if result.MatchedCount == 0 {
+		return c.Status(404).JSON(APIKeyResponse{
+			Success: false,
+			Message: "API key not found",
+		})
+	}
+
+	return c.JSON(APIKeyResponse{
+		Success: true,
+		Message: "API key revoked successfully",
+	})
+}
@@ -17,22 +17,29 @@ type FavoriteResponse struct {
 	Message string      `json:"message,omitempty"`
 }
 
-// GetFavorites returns all favorite properties of the authenticated user
+// GetFavorites returns all favorite properties of the authenticated user.
+// Whole properties are served from the cache, selected fields are loaded
+// with a projection.
 func GetFavorites(c *fiber.Ctx) error {
 	// Get user ID from context (set by auth middleware)
 	userID := c.Locals("user_id").(string)
 
+	fields, err := services.ParseFieldSet(c.Query("fields"))
+	if err != nil {
+		return c.Status(400).JSON(FavoriteResponse{
+			Success: false,
+			Message: "Invalid fields: " + err.Error(),
+		})
+	}
+
 	// Try to get from cache first
 	favPropsKey := services.GetCacheKey("user_favorite_properties", userID, "")
-	var cachedProperties []models.Property
-
-	err := services.GetCache(favPropsKey, &cachedProperties)
-	if err == nil && len(cachedProperties) >= 0 {
-		// Cache hit - return cached data
-		return c.JSON(FavoriteResponse{
-			Success: true,
-			Data:    cachedProperties,
-		})
+	if fields == nil {
+		var cachedProperties []models.Property
+		if err := services.GetCache(favPropsKey, &cachedProperties); err == nil {
+			// Cache hit - return cached data
+			return favoritesJSON(c, nil, cachedProperties)
+		}
 	}
 
 	// Cache miss - fetch from database
@@ -64,6 +71,18 @@ func GetFavorites(c *fiber.Ctx) error {
 		})
 	}
 
+	// Partial properties are not cached
+	if fields != nil {
+		properties, _, err := findPropertiesByIDs(user.Favorites, fields)
+		if err != nil {
+			return c.Status(500).JSON(FavoriteResponse{
+				Success: false,
+				Message: "Failed to fetch favorite properties",
+			})
+		}
+		return favoritesJSON(c, fields, properties)
+	}
+
 	// Find all favorite properties
 	var properties []models.Property
 	cursor, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), bson.M{
@@ -91,9 +110,22 @@ func GetFavorites(c *fiber.Ctx) error {
 	favKey := services.GetCacheKey("user_favorites", userID, "")
 	services.SetCache(favKey, user.Favorites)
 
+	return favoritesJSON(c, nil, properties)
+}
+
+// favoritesJSON responds with the selected fields of the favorite properties
+func favoritesJSON(c *fiber.Ctx, fields *services.FieldSet, properties []models.Property) error {
+	data, err := fields.Apply(properties)
+	if err != nil {
+		return c.Status(500).JSON(FavoriteResponse{
+			Success: false,
+			Message: "Failed to decode properties",
+		})
+	}
+
 	return c.JSON(FavoriteResponse{
 		Success: true,
-		Data:    properties,
+		Data:    data,
 	})
 }
 
@@ -192,6 +224,7 @@ func AddToFavorites(c *fiber.Ctx) error {
 	log.Printf("Successfully added property %s to favorites for user %s", propertyID, userID)
 	// Update cache after successful database update
 	go services.UpdateFavoritesCache(userID)
+	services.InvalidateUserFeed(userID)
 
 	return c.JSON(FavoriteResponse{
 		Success: true,
@@ -237,6 +270,7 @@ func RemoveFromFavorites(c *fiber.Ctx) error {
 
 	// Update cache after successful database update
 	go services.UpdateFavoritesCache(userID)
+	services.InvalidateUserFeed(userID)
 
 	return c.JSON(FavoriteResponse{
 		Success: true,
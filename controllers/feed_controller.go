@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"strconv"
+
+	"property_lister/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FeedResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Message string      `json:"message,omitempty"`
+	Meta    *FeedMeta   `json:"meta,omitempty"`
+}
+
+type FeedMeta struct {
+	Personalized bool                        `json:"personalized"`
+	Profile      *services.PreferenceProfile `json:"profile,omitempty"`
+	Limit        int                         `json:"limit"`
+	Count        int                         `json:"count"`
+}
+
+// GetFeed handles GET /api/feed, the properties recommended to the
+// authenticated user from their favorites and accepted recommendations
+func GetFeed(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	limit, _ := strconv.Atoi(c.Query("limit", strconv.Itoa(services.DefaultFeedLimit)))
+	if limit < 1 {
+		limit = services.DefaultFeedLimit
+	}
+	if limit > services.MaxFeedLimit {
+		limit = services.MaxFeedLimit
+	}
+
+	feed, err := services.GetUserFeed(userID)
+	if err != nil {
+		return c.Status(500).JSON(FeedResponse{
+			Success: false,
+			Message: "Failed to build feed",
+		})
+	}
+
+	items := feed.Items
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	return c.JSON(FeedResponse{
+		Success: true,
+		Data:    items,
+		Meta: &FeedMeta{
+			Personalized: feed.Personalized,
+			Profile:      feed.Profile,
+			Limit:        limit,
+			Count:        len(items),
+		},
+	})
+}
@@ -0,0 +1,16 @@
+package controllers
+
+import (
+	"property_lister/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetJWKS handles GET /.well-known/jwks.json and publishes every key that
+// tokens may currently be verified with
+func GetJWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(fiber.Map{
+		"keys": config.JWTKeys.JWKS(),
+	})
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"fmt"
+	"log"
 	"strconv"
 	"time"
 
@@ -54,6 +55,17 @@ type UpdateListingRequest struct {
 	ListingType   string   `json:"listingType" validate:"omitempty,oneof=rent sale"`
 }
 
+// canManageListing reports whether the current user may modify a listing.
+// System-ingested inventory is reserved for admins, everything else can be
+// managed by its creator or an admin.
+func canManageListing(c *fiber.Ctx, property *models.Property) bool {
+	if role, _ := c.Locals("role").(string); role == models.RoleAdmin {
+		return true
+	}
+	userID := c.Locals("user_id").(string)
+	return property.CreatedBy != "SYSTEM" && property.CreatedBy == userID
+}
+
 // GetListings handles GET /api/listings
 func GetListings(c *fiber.Ctx) error {
 	// Get user ID from context (set by auth middleware)
@@ -70,31 +82,57 @@ func GetListings(c *fiber.Ctx) error {
 		limit = 10
 	}
 
+	fields, err := services.ParseFieldSet(c.Query("fields"))
+	if err != nil {
+		return c.Status(400).JSON(ListingResponse{
+			Success: false,
+			Message: "Invalid fields: " + err.Error(),
+		})
+	}
+
+	// Newest first, _id keeps the order stable for cursors
+	sort := services.UserListingsSort
+
+	// A cursor takes precedence over the page number
+	var cursor *services.Cursor
+	if token := c.Query("cursor"); token != "" {
+		cursor, err = services.DecodeCursor(token, sort)
+		if err != nil {
+			return c.Status(400).JSON(ListingResponse{
+				Success: false,
+				Message: "Invalid cursor",
+			})
+		}
+		page = 0
+	}
+
 	// Try to get from cache first (only for first page with default limit)
 	if page == 1 && limit == 10 {
 		listingsKey := services.GetCacheKey("user_listings", userID, "")
 		var cachedListings []models.Property
 		if err := services.GetCache(listingsKey, &cachedListings); err == nil {
-			// Calculate pagination metadata for cached data
-			total := int64(len(cachedListings))
-			totalPages := int((total + int64(limit) - 1) / int64(limit))
-
 			// Return first page from cache
-			end := limit
+			end := limit + 1
 			if end > len(cachedListings) {
 				end = len(cachedListings)
 			}
 
-			return c.JSON(ListingResponse{
-				Success: true,
-				Data:    cachedListings[:end],
-				Meta: &types.PaginationMeta{
-					Page:       page,
-					Limit:      limit,
-					Total:      total,
-					TotalPages: totalPages,
-				},
-			})
+			listings, nextCursor, _, err := services.CursorPage(cachedListings[:end], limit, sort, nil, false)
+			var data interface{}
+			if err == nil {
+				data, err = fields.Apply(listings)
+			}
+			if err == nil {
+				// Calculate pagination metadata for cached data
+				meta := &types.PaginationMeta{Page: page, Limit: limit, NextCursor: nextCursor}
+				meta.Total, meta.TotalPages = types.PageTotals(int64(len(cachedListings)), limit)
+
+				return c.JSON(ListingResponse{
+					Success: true,
+					Data:    data,
+					Meta:    meta,
+				})
+			}
 		}
 	}
 
@@ -103,38 +141,61 @@ func GetListings(c *fiber.Ctx) error {
 	filter := bson.M{"created_by": userID}
 
 	// Calculate skip value
-	skip := (page - 1) * limit
+	skip := 0
+	if cursor == nil {
+		skip = (page - 1) * limit
+	}
+
+	// Counting is skipped for cursor pages unless asked for, it costs as much
+	// as the deep pages cursors avoid
+	meta := &types.PaginationMeta{Page: page, Limit: limit}
+	if cursor == nil || c.Query("with_total") == "true" {
+		total, err := mgm.Coll(&models.Property{}).CountDocuments(mgm.Ctx(), filter)
+		if err != nil {
+			return c.Status(500).JSON(ListingResponse{
+				Success: false,
+				Message: "Failed to count listings",
+			})
+		}
+		meta.Total, meta.TotalPages = types.PageTotals(total, limit)
+	}
 
-	// Setup find options
+	// Setup find options, one extra item tells whether there is a next page
+	pageFilter := filter
+	if cursor != nil {
+		pageFilter = bson.M{"$and": bson.A{filter, cursor.Filter(sort)}}
+	}
 	findOptions := options.Find()
-	findOptions.SetLimit(int64(limit))
+	findOptions.SetLimit(int64(limit + 1))
 	findOptions.SetSkip(int64(skip))
-	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}}) // Sort by newest first
+	findOptions.SetSort(cursor.QuerySort(sort))
+	if projection := fields.Projection(sort); projection != nil {
+		findOptions.SetProjection(projection)
+	}
 
-	// Get total count
-	total, err := mgm.Coll(&models.Property{}).CountDocuments(mgm.Ctx(), filter)
+	// Find properties
+	properties := []models.Property{}
+	results, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), pageFilter, findOptions)
 	if err != nil {
 		return c.Status(500).JSON(ListingResponse{
 			Success: false,
-			Message: "Failed to count listings",
+			Message: "Failed to fetch listings",
 		})
 	}
+	defer results.Close(mgm.Ctx())
 
-	// Find properties
-	var properties []models.Property
-	cursor, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), filter, findOptions)
-	if err != nil {
+	if err = results.All(mgm.Ctx(), &properties); err != nil {
 		return c.Status(500).JSON(ListingResponse{
 			Success: false,
-			Message: "Failed to fetch listings",
+			Message: "Failed to decode listings",
 		})
 	}
-	defer cursor.Close(mgm.Ctx())
 
-	if err = cursor.All(mgm.Ctx(), &properties); err != nil {
+	properties, nextCursor, prevCursor, err := services.CursorPage(properties, limit, sort, cursor, skip > 0)
+	if err != nil {
 		return c.Status(500).JSON(ListingResponse{
 			Success: false,
-			Message: "Failed to decode listings",
+			Message: "Failed to build pagination cursors",
 		})
 	}
 
@@ -143,7 +204,7 @@ func GetListings(c *fiber.Ctx) error {
 		// Fetch all listings for caching (not just the page)
 		var allListings []models.Property
 		cursor, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), filter,
-			options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+			options.Find().SetSort(sort))
 		if err == nil {
 			cursor.All(mgm.Ctx(), &allListings)
 			cursor.Close(mgm.Ctx())
@@ -153,18 +214,20 @@ func GetListings(c *fiber.Ctx) error {
 		}
 	}
 
-	// Calculate total pages
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	data, err := fields.Apply(properties)
+	if err != nil {
+		return c.Status(500).JSON(ListingResponse{
+			Success: false,
+			Message: "Failed to decode listings",
+		})
+	}
+
+	meta.NextCursor, meta.PrevCursor = nextCursor, prevCursor
 
 	return c.JSON(ListingResponse{
 		Success: true,
-		Data:    properties,
-		Meta: &types.PaginationMeta{
-			Page:       page,
-			Limit:      limit,
-			Total:      total,
-			TotalPages: totalPages,
-		},
+		Data:    data,
+		Meta:    meta,
 	})
 }
 
@@ -179,12 +242,20 @@ func CreateListing(c *fiber.Ctx) error {
 		})
 	}
 
+	availableFrom, err := models.ParseDate(req.AvailableFrom)
+	if err != nil {
+		return c.Status(400).JSON(ListingResponse{
+			Success: false,
+			Message: "Invalid availableFrom: " + err.Error(),
+		})
+	}
+
 	// Get user ID from context (set by auth middleware)
 	userID := c.Locals("user_id").(string)
 
 	// Find the highest property ID
 	var lastProperty models.Property
-	err := mgm.Coll(&models.Property{}).
+	err = mgm.Coll(&models.Property{}).
 		FindOne(
 			mgm.Ctx(),
 			bson.M{},
@@ -224,9 +295,10 @@ func CreateListing(c *fiber.Ctx) error {
 		Bathrooms:     req.Bathrooms,
 		Amenities:     req.Amenities,
 		Furnished:     req.Furnished,
-		AvailableFrom: req.AvailableFrom,
+		AvailableFrom: availableFrom,
 		Tags:          req.Tags,
 		ListingType:   req.ListingType,
+		Location:      services.LookupCityLocation(req.City, req.State),
 		CreatedBy:     userID,
 		CreatedAt:     now,
 		UpdatedAt:     now,
@@ -244,6 +316,10 @@ func CreateListing(c *fiber.Ctx) error {
 	// Update cache after successful creation
 	go services.UpdateListingsCache(userID)
 
+	// Alert users whose saved searches match the new listing
+	go services.MatchSavedSearches(property)
+	go services.RefreshSuggestions()
+
 	return c.Status(201).JSON(ListingResponse{
 		Success: true,
 		Message: "Listing created successfully",
@@ -261,9 +337,6 @@ func UpdateListing(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get user ID from context (set by auth middleware)
-	userID := c.Locals("user_id").(string)
-
 	// Find the existing property
 	var property models.Property
 	err := mgm.Coll(&property).FindOne(mgm.Ctx(), bson.M{"id": id}).Decode(&property)
@@ -275,7 +348,7 @@ func UpdateListing(c *fiber.Ctx) error {
 	}
 
 	// Check ownership
-	if property.CreatedBy != userID && property.CreatedBy != "SYSTEM" {
+	if !canManageListing(c, &property) {
 		return c.Status(403).JSON(ListingResponse{
 			Success: false,
 			Message: "You don't have permission to update this listing",
@@ -323,7 +396,14 @@ func UpdateListing(c *fiber.Ctx) error {
 		update["furnished"] = req.Furnished
 	}
 	if req.AvailableFrom != "" {
-		update["availableFrom"] = req.AvailableFrom
+		availableFrom, err := models.ParseDate(req.AvailableFrom)
+		if err != nil {
+			return c.Status(400).JSON(ListingResponse{
+				Success: false,
+				Message: "Invalid availableFrom: " + err.Error(),
+			})
+		}
+		update["availableFrom"] = availableFrom
 	}
 	if req.Tags != nil {
 		update["tags"] = req.Tags
@@ -332,10 +412,30 @@ func UpdateListing(c *fiber.Ctx) error {
 		update["listingType"] = req.ListingType
 	}
 
+	changes := bson.M{"$set": update}
+
+	// Moving the listing to another city moves its location with it
+	if req.City != "" || req.State != "" {
+		city, state := property.City, property.State
+		if req.City != "" {
+			city = req.City
+		}
+		if req.State != "" {
+			state = req.State
+		}
+
+		if location := services.LookupCityLocation(city, state); location != nil {
+			update["location"] = location
+		} else {
+			changes["$unset"] = bson.M{"location": ""}
+			property.Location = nil
+		}
+	}
+
 	_, err = mgm.Coll(&property).UpdateOne(
 		mgm.Ctx(),
 		bson.M{"id": id},
-		bson.M{"$set": update},
+		changes,
 	)
 
 	if err != nil {
@@ -354,8 +454,12 @@ func UpdateListing(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update cache after successful update
-	go services.UpdateListingsCache(userID)
+	// Update the owner's cache after successful update
+	go services.UpdateListingsCache(property.CreatedBy)
+
+	// The listing may match saved searches it didn't match before
+	go services.MatchSavedSearches(&property)
+	go services.RefreshSuggestions()
 
 	return c.JSON(ListingResponse{
 		Success: true,
@@ -374,9 +478,6 @@ func DeleteListing(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get user ID from context (set by auth middleware)
-	userID := c.Locals("user_id").(string)
-
 	// Find the property first to check ownership
 	var property models.Property
 	err := mgm.Coll(&property).FindOne(mgm.Ctx(), bson.M{"id": id}).Decode(&property)
@@ -388,7 +489,7 @@ func DeleteListing(c *fiber.Ctx) error {
 	}
 
 	// Check ownership
-	if property.CreatedBy != userID && property.CreatedBy != "SYSTEM" {
+	if !canManageListing(c, &property) {
 		return c.Status(403).JSON(ListingResponse{
 			Success: false,
 			Message: "You don't have permission to delete this listing",
@@ -403,8 +504,13 @@ func DeleteListing(c *fiber.Ctx) error {
 		})
 	}
 
-	// Update cache after successful deletion
-	go services.UpdateListingsCache(userID)
+	// Update the owner's cache after successful deletion
+	go services.UpdateListingsCache(property.CreatedBy)
+
+	if err := services.DeleteSearchAlertsForProperties([]string{property.ID}); err != nil {
+		log.Printf("Failed to delete saved search alerts for %s: %v", property.ID, err)
+	}
+	go services.RefreshSuggestions()
 
 	return c.JSON(ListingResponse{
 		Success: true,
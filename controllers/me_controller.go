@@ -0,0 +1,318 @@
+package controllers
+
+import (
+	"bytes"
+	"time"
+
+	"property_lister/models"
+	"property_lister/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type ProfileResponse struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
+
+// Fields are pointers so that omitted fields are left untouched while
+// empty strings can still clear optional values like phone
+type UpdateProfileRequest struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Phone     *string `json:"phone"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// findCurrentUser loads the authenticated user from the database
+func findCurrentUser(c *fiber.Ctx) (*models.User, error) {
+	objID, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	err = mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": objID}).Decode(&user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetMe handles GET /api/me
+func GetMe(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	// Try to get from cache first
+	profileKey := services.GetCacheKey("user_profile", userID, "")
+	var cachedUser models.User
+	if err := services.GetCache(profileKey, &cachedUser); err == nil {
+		return c.JSON(ProfileResponse{
+			Success: true,
+			Data:    toUserResponse(&cachedUser),
+		})
+	}
+
+	user, err := findCurrentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(ProfileResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	services.SetCache(profileKey, user)
+
+	return c.JSON(ProfileResponse{
+		Success: true,
+		Data:    toUserResponse(user),
+	})
+}
+
+// UpdateMe handles PATCH /api/me
+func UpdateMe(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req UpdateProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+	}
+
+	// Update only provided fields
+	update := bson.M{"updated_at": time.Now()}
+	if req.FirstName != nil {
+		if *req.FirstName == "" {
+			return c.Status(400).JSON(ProfileResponse{
+				Success: false,
+				Message: "First name cannot be empty",
+			})
+		}
+		update["first_name"] = *req.FirstName
+	}
+	if req.LastName != nil {
+		if *req.LastName == "" {
+			return c.Status(400).JSON(ProfileResponse{
+				Success: false,
+				Message: "Last name cannot be empty",
+			})
+		}
+		update["last_name"] = *req.LastName
+	}
+	if req.Phone != nil {
+		update["phone"] = *req.Phone
+	}
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	var user models.User
+	err = mgm.Coll(&user).FindOneAndUpdate(
+		mgm.Ctx(),
+		bson.M{"_id": objID},
+		bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err != nil {
+		return c.Status(404).JSON(ProfileResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	// Keep the cached profile in sync
+	services.SetCache(services.GetCacheKey("user_profile", userID, ""), &user)
+
+	return c.JSON(ProfileResponse{
+		Success: true,
+		Message: "Profile updated successfully",
+		Data:    toUserResponse(&user),
+	})
+}
+
+// ChangePassword handles POST /api/me/password. All other sessions are
+// revoked and a fresh token pair is returned for the current client.
+func ChangePassword(c *fiber.Ctx) error {
+	var req ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Current password and new password are required",
+		})
+	}
+
+	if len(req.NewPassword) < 6 {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Password must be at least 6 characters long",
+		})
+	}
+
+	user, err := findCurrentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(AuthResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "Current password is incorrect",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to hash password",
+		})
+	}
+
+	user.UpdatedAt = time.Now()
+	_, err = mgm.Coll(user).UpdateOne(mgm.Ctx(), bson.M{"_id": user.ID}, bson.M{
+		"$set": bson.M{"password": string(hashedPassword), "updated_at": user.UpdatedAt},
+	})
+	if err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to change password",
+		})
+	}
+
+	// Sign out every other session, then hand this client new tokens
+	if err := services.RevokeAllUserTokens(user.ID.Hex()); err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to revoke existing sessions",
+		})
+	}
+
+	tokens, err := services.GenerateTokenPair(user)
+	if err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to generate token",
+		})
+	}
+
+	return c.JSON(AuthResponse{
+		Success: true,
+		Message: "Password changed successfully",
+		Data:    toAuthData(user, tokens),
+	})
+}
+
+// DeleteMe handles DELETE /api/me
+func DeleteMe(c *fiber.Ctx) error {
+	var req DeleteAccountRequest
+	if err := c.BodyParser(&req); err != nil || req.Password == "" {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Password is required to delete your account",
+		})
+	}
+
+	user, err := findCurrentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(ProfileResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		return c.Status(401).JSON(ProfileResponse{
+			Success: false,
+			Message: "Password is incorrect",
+		})
+	}
+
+	// Removes the account along with its listings and recommendations
+	if err := services.EraseUser(user); err != nil {
+		return c.Status(500).JSON(ProfileResponse{
+			Success: false,
+			Message: "Failed to delete account",
+		})
+	}
+
+	return c.JSON(ProfileResponse{
+		Success: true,
+		Message: "Account deleted successfully",
+	})
+}
+
+// ExportMe handles GET /api/me/export?format=json|zip and returns every
+// piece of data stored about the user as a download
+func ExportMe(c *fiber.Ctx) error {
+	format := c.Query("format", "json")
+	if format != "json" && format != "zip" {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Format must be either json or zip",
+		})
+	}
+
+	user, err := findCurrentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(ProfileResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	export, err := services.BuildUserExport(user)
+	if err != nil {
+		return c.Status(500).JSON(ProfileResponse{
+			Success: false,
+			Message: "Failed to export user data",
+		})
+	}
+
+	if format == "zip" {
+		var buf bytes.Buffer
+		if err := services.WriteUserExportZip(&buf, export); err != nil {
+			return c.Status(500).JSON(ProfileResponse{
+				Success: false,
+				Message: "Failed to build export archive",
+			})
+		}
+
+		c.Attachment("user-data-export.zip")
+		c.Set(fiber.HeaderContentType, "application/zip")
+		return c.Send(buf.Bytes())
+	}
+
+	c.Attachment("user-data-export.json")
+	return c.JSON(export)
+}
@@ -1,28 +1,53 @@
 package controllers
 
 import (
+	"bufio"
+	"fmt"
+	"log"
 	"strconv"
+	"strings"
 
 	"property_lister/models"
+	"property_lister/services"
+	"property_lister/types"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/kamva/mgm/v3"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type PropertyResponse struct {
-	Success bool            `json:"success"`
-	Data    interface{}     `json:"data,omitempty"`
-	Message string          `json:"message,omitempty"`
-	Meta    *PaginationMeta `json:"meta,omitempty"`
+	Success bool                   `json:"success"`
+	Data    interface{}            `json:"data,omitempty"`
+	Message string                 `json:"message,omitempty"`
+	Meta    *PaginationMeta        `json:"meta,omitempty"`
+	Facets  map[string]interface{} `json:"facets,omitempty"`
+
+	// Corrections maps misspelled search terms to the known words searched along
+	Corrections map[string]string `json:"corrections,omitempty"`
 }
 
 type PaginationMeta struct {
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	Total      int64 `json:"total"`
-	TotalPages int   `json:"total_pages"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      *int64 `json:"total,omitempty"`
+	TotalPages *int   `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Maximum number of properties looked up in one batch
+const maxBatchProperties = 100
+
+type BatchPropertiesRequest struct {
+	IDs []string `json:"ids"`
+}
+
+type BatchPropertiesResult struct {
+	Properties interface{} `json:"properties"`
+	Missing    []string    `json:"missing"`
 }
 
 // GetProperties handles GET /api/properties with filtering and pagination
@@ -40,99 +65,37 @@ func GetProperties(c *fiber.Ctx) error {
 	}
 
 	// Build filter
-	filter := bson.M{}
-
-	// Price range filter
-	if minPrice := c.Query("min_price"); minPrice != "" {
-		if min, err := strconv.Atoi(minPrice); err == nil {
-			filter["price"] = bson.M{"$gte": min}
-		}
-	}
-	if maxPrice := c.Query("max_price"); maxPrice != "" {
-		if max, err := strconv.Atoi(maxPrice); err == nil {
-			if existing, ok := filter["price"].(bson.M); ok {
-				existing["$lte"] = max
-			} else {
-				filter["price"] = bson.M{"$lte": max}
-			}
-		}
-	}
-
-	// Location filters (case-insensitive)
-	if state := c.Query("state"); state != "" {
-		filter["state"] = bson.M{"$regex": state, "$options": "i"}
-	}
-	if city := c.Query("city"); city != "" {
-		filter["city"] = bson.M{"$regex": city, "$options": "i"}
-	}
-
-	// Property type filter (case-insensitive)
-	if propType := c.Query("type"); propType != "" {
-		filter["type"] = bson.M{"$regex": propType, "$options": "i"}
-	}
-
-	// Listing type filter (case-insensitive)
-	if listingType := c.Query("listing_type"); listingType != "" {
-		filter["listingType"] = bson.M{"$regex": listingType, "$options": "i"}
-	}
-
-	// Furnished filter (case-insensitive)
-	if furnished := c.Query("furnished"); furnished != "" {
-		filter["furnished"] = bson.M{"$regex": furnished, "$options": "i"}
-	}
-
-	// Amenities filter (case-insensitive) - matches if any amenity contains the search term
-	if amenities := c.Query("amenities"); amenities != "" {
-		filter["amenities"] = bson.M{"$regex": amenities, "$options": "i"}
-	}
-
-	// Tags filter (case-insensitive) - matches if any tag contains the search term
-	if tags := c.Query("tags"); tags != "" {
-		filter["tags"] = bson.M{"$regex": tags, "$options": "i"}
-	}
-
-	// Title filter (case-insensitive)
-	if title := c.Query("title"); title != "" {
-		filter["title"] = bson.M{"$regex": title, "$options": "i"}
-	}
-
-	// Bedrooms filter
-	if bedrooms := c.Query("bedrooms"); bedrooms != "" {
-		if beds, err := strconv.Atoi(bedrooms); err == nil {
-			filter["bedrooms"] = beds
-		}
+	query, err := services.BuildPropertyFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid filter: " + err.Error(),
+		})
 	}
+	filter := query.Filter
 
-	// Bathrooms filter
-	if bathrooms := c.Query("bathrooms"); bathrooms != "" {
-		if baths, err := strconv.Atoi(bathrooms); err == nil {
-			filter["bathrooms"] = baths
-		}
+	fields, err := services.ParseFieldSet(c.Query("fields"))
+	if err != nil {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid fields: " + err.Error(),
+		})
 	}
 
-	// Area filter (square feet range)
-	if minArea := c.Query("min_area"); minArea != "" {
-		if min, err := strconv.Atoi(minArea); err == nil {
-			filter["areaSqFt"] = bson.M{"$gte": min}
+	// Facet counts are only computed when asked for
+	var facetNames []string
+	if facetsParam := c.Query("facets"); facetsParam != "" {
+		facetNames, err = services.ParseFacets(facetsParam)
+		if err != nil {
+			return c.Status(400).JSON(PropertyResponse{
+				Success: false,
+				Message: "Invalid facets: " + err.Error(),
+			})
 		}
 	}
-	if maxArea := c.Query("max_area"); maxArea != "" {
-		if max, err := strconv.Atoi(maxArea); err == nil {
-			if existing, ok := filter["areaSqFt"].(bson.M); ok {
-				existing["$lte"] = max
-			} else {
-				filter["areaSqFt"] = bson.M{"$lte": max}
-			}
-		}
-	}
-
-	// Verified filter
-	if verified := c.Query("verified"); verified != "" {
-		if verified == "true" {
-			filter["isVerified"] = true
-		} else if verified == "false" {
-			filter["isVerified"] = false
-		}
+	priceBuckets, _ := strconv.Atoi(c.Query("price_buckets", strconv.Itoa(services.DefaultPriceBuckets)))
+	if priceBuckets < 1 || priceBuckets > services.MaxPriceBuckets {
+		priceBuckets = services.DefaultPriceBuckets
 	}
 
 	// Setup sorting
@@ -140,14 +103,17 @@ func GetProperties(c *fiber.Ctx) error {
 	sortBy := c.Query("sort_by", "")
 	sortOrder := c.Query("sort_order", "asc")
 
-	if sortBy != "" {
-		order := 1
-		if sortOrder == "desc" {
-			order = -1
-		}
+	order := 1
+	if sortOrder == "desc" {
+		order = -1
+	}
 
+	if query.Near != nil && (sortBy == "" || sortBy == "distance") {
+		// Near-point results are ordered by distance by default
+		sort = append(sort, bson.E{Key: "distance_km", Value: order})
+	} else if sortBy != "" {
 		switch sortBy {
-		case "price", "rating", "areaSqFt", "bedrooms", "bathrooms":
+		case "price", "rating", "areaSqFt", "bedrooms", "bathrooms", "availableFrom":
 			sort = append(sort, bson.E{Key: sortBy, Value: order})
 		default:
 			sort = append(sort, bson.E{Key: "price", Value: 1})
@@ -155,58 +121,158 @@ func GetProperties(c *fiber.Ctx) error {
 	} else {
 		sort = append(sort, bson.E{Key: "price", Value: 1})
 	}
+	sort = services.WithIDTiebreaker(sort)
+
+	// A cursor takes precedence over the page number
+	var cursor *services.Cursor
+	if token := c.Query("cursor"); token != "" {
+		cursor, err = services.DecodeCursor(token, sort)
+		if err != nil {
+			return c.Status(400).JSON(PropertyResponse{
+				Success: false,
+				Message: "Invalid cursor",
+			})
+		}
+		page = 0
+	}
 
 	// Calculate skip value
-	skip := (page - 1) * limit
-
-	// Setup find options
-	findOptions := options.Find()
-	findOptions.SetLimit(int64(limit))
-	findOptions.SetSkip(int64(skip))
-	findOptions.SetSort(sort)
+	skip := 0
+	if cursor == nil {
+		skip = (page - 1) * limit
+	}
 
-	// Get total count
-	total, err := mgm.Coll(&models.Property{}).CountDocuments(mgm.Ctx(), filter)
-	if err != nil {
-		return c.Status(500).JSON(PropertyResponse{
-			Success: false,
-			Message: "Failed to count properties",
-		})
+	// Counting is skipped for cursor pages unless asked for, it costs as much
+	// as the deep pages cursors avoid
+	meta := &PaginationMeta{Page: page, Limit: limit}
+	if cursor == nil || c.Query("with_total") == "true" {
+		total, err := mgm.Coll(&models.Property{}).CountDocuments(mgm.Ctx(), filter)
+		if err != nil {
+			return c.Status(500).JSON(PropertyResponse{
+				Success: false,
+				Message: "Failed to count properties",
+			})
+		}
+		meta.Total, meta.TotalPages = types.PageTotals(total, limit)
 	}
 
 	// Find properties
-	var properties []models.Property
-	cursor, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), filter, findOptions)
+	var properties interface{}
+	var nextCursor, prevCursor string
+	if query.Near != nil {
+		properties, nextCursor, prevCursor, err = findPropertiesNear(query, fields, sort, cursor, skip, limit)
+	} else {
+		properties, nextCursor, prevCursor, err = findProperties(filter, fields, sort, cursor, skip, limit)
+	}
+	if err == nil {
+		properties, err = fields.Apply(properties)
+	}
 	if err != nil {
 		return c.Status(500).JSON(PropertyResponse{
 			Success: false,
 			Message: "Failed to fetch properties",
 		})
 	}
-	defer cursor.Close(mgm.Ctx())
 
-	if err = cursor.All(mgm.Ctx(), &properties); err != nil {
-		return c.Status(500).JSON(PropertyResponse{
-			Success: false,
-			Message: "Failed to decode properties",
-		})
+	var facets map[string]interface{}
+	if len(facetNames) > 0 {
+		facets, err = services.GetPropertyFacets(filter, facetNames, priceBuckets)
+		if err != nil {
+			return c.Status(500).JSON(PropertyResponse{
+				Success: false,
+				Message: "Failed to compute facets",
+			})
+		}
 	}
 
-	// Calculate total pages
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	meta.NextCursor, meta.PrevCursor = nextCursor, prevCursor
 
 	return c.JSON(PropertyResponse{
 		Success: true,
 		Data:    properties,
-		Meta: &PaginationMeta{
-			Page:       page,
-			Limit:      limit,
-			Total:      total,
-			TotalPages: totalPages,
-		},
+		Meta:    meta,
+		Facets:  facets,
 	})
 }
 
+// findProperties returns one page of properties matching the filter,
+// starting at skip or at the cursor, with the cursors of the adjacent pages.
+// Only the selected fields are loaded.
+func findProperties(filter bson.M, fields *services.FieldSet, sort bson.D, cursor *services.Cursor, skip, limit int) ([]models.Property, string, string, error) {
+	if cursor != nil {
+		filter = bson.M{"$and": bson.A{filter, cursor.Filter(sort)}}
+	}
+
+	// One extra item tells whether there is a next page
+	findOptions := options.Find()
+	findOptions.SetLimit(int64(limit + 1))
+	findOptions.SetSkip(int64(skip))
+	findOptions.SetSort(cursor.QuerySort(sort))
+	if projection := fields.Projection(sort); projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
+	properties := []models.Property{}
+	results, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), filter, findOptions)
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer results.Close(mgm.Ctx())
+
+	if err = results.All(mgm.Ctx(), &properties); err != nil {
+		return nil, "", "", err
+	}
+	return services.CursorPage(properties, limit, sort, cursor, skip > 0)
+}
+
+// findPropertiesNear runs a $geoNear aggregation so every result carries its
+// distance from the query point
+func findPropertiesNear(query *services.PropertyQuery, fields *services.FieldSet, sort bson.D, cursor *services.Cursor, skip, limit int) ([]models.PropertyWithDistance, string, string, error) {
+	// $geoNear does the radius check itself, the rest of the filter goes into its query
+	nearFilter := bson.M{}
+	for key, value := range query.Filter {
+		if key != "location" {
+			nearFilter[key] = value
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.M{
+			"near":               query.Near.Point,
+			"key":                "location",
+			"distanceField":      "distance_km",
+			"distanceMultiplier": 0.001,
+			"maxDistance":        query.Near.RadiusKm * 1000,
+			"spherical":          true,
+			"query":              nearFilter,
+		}}},
+	}
+	if cursor != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: cursor.Filter(sort)}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: cursor.QuerySort(sort)}},
+		bson.D{{Key: "$skip", Value: skip}},
+		bson.D{{Key: "$limit", Value: limit + 1}},
+	)
+	if projection := fields.Projection(sort); projection != nil {
+		projection["distance_km"] = 1
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection}})
+	}
+
+	properties := []models.PropertyWithDistance{}
+	results, err := mgm.Coll(&models.Property{}).Aggregate(mgm.Ctx(), pipeline)
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer results.Close(mgm.Ctx())
+
+	if err = results.All(mgm.Ctx(), &properties); err != nil {
+		return nil, "", "", err
+	}
+	return services.CursorPage(properties, limit, sort, cursor, skip > 0)
+}
+
 // GetPropertyByID handles GET /api/properties/:id
 func GetPropertyByID(c *fiber.Ctx) error {
 	id := c.Params("id")
@@ -217,8 +283,21 @@ func GetPropertyByID(c *fiber.Ctx) error {
 		})
 	}
 
+	fields, err := services.ParseFieldSet(c.Query("fields"))
+	if err != nil {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid fields: " + err.Error(),
+		})
+	}
+
+	findOptions := options.FindOne()
+	if projection := fields.Projection(nil); projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
 	var property models.Property
-	err := mgm.Coll(&property).FindOne(mgm.Ctx(), bson.M{"id": id}).Decode(&property)
+	err = mgm.Coll(&property).FindOne(mgm.Ctx(), bson.M{"id": id}, findOptions).Decode(&property)
 	if err != nil {
 		return c.Status(404).JSON(PropertyResponse{
 			Success: false,
@@ -226,13 +305,21 @@ func GetPropertyByID(c *fiber.Ctx) error {
 		})
 	}
 
+	data, err := fields.Apply(property)
+	if err != nil {
+		return c.Status(500).JSON(PropertyResponse{
+			Success: false,
+			Message: "Failed to fetch property",
+		})
+	}
+
 	return c.JSON(PropertyResponse{
 		Success: true,
-		Data:    property,
+		Data:    data,
 	})
 }
 
-// SearchProperties handles GET /api/properties/search with text search
+// SearchProperties handles GET /api/properties/search with full-text search ranked by relevance
 func SearchProperties(c *fiber.Ctx) error {
 	query := c.Query("q")
 	if query == "" {
@@ -253,66 +340,388 @@ func SearchProperties(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	// Build search filter using regex for multiple fields
+	// Parse the query ourselves so user input never reaches MongoDB as operators
+	textQuery, err := services.ParseSearchQuery(query)
+	if err != nil {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid search query: " + err.Error(),
+		})
+	}
+
+	fields, err := services.ParseFieldSet(c.Query("fields"))
+	if err != nil {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid fields: " + err.Error(),
+		})
+	}
+
+	// Correct typos and add synonyms before the query reaches MongoDB
+	services.ExpandSearchQuery(textQuery)
+
 	searchFilter := bson.M{
-		"$or": []bson.M{
-			{"title": bson.M{"$regex": query, "$options": "i"}},
-			{"state": bson.M{"$regex": query, "$options": "i"}},
-			{"city": bson.M{"$regex": query, "$options": "i"}},
-			{"type": bson.M{"$regex": query, "$options": "i"}},
-			{"amenities": bson.M{"$regex": query, "$options": "i"}},
-			{"tags": bson.M{"$regex": query, "$options": "i"}},
-		},
+		"$text": bson.M{"$search": textQuery.MongoSearch()},
+	}
+
+	// Most relevant first, the score is a field so it can be part of a cursor
+	sort := bson.D{
+		{Key: "score", Value: -1},
+		{Key: "rating", Value: -1},
+		{Key: "_id", Value: 1},
+	}
+
+	// A cursor takes precedence over the page number
+	var cursor *services.Cursor
+	if token := c.Query("cursor"); token != "" {
+		cursor, err = services.DecodeCursor(token, sort)
+		if err != nil {
+			return c.Status(400).JSON(PropertyResponse{
+				Success: false,
+				Message: "Invalid cursor",
+			})
+		}
+		page = 0
 	}
 
 	// Calculate skip
-	skip := (page - 1) * limit
+	skip := 0
+	if cursor == nil {
+		skip = (page - 1) * limit
+	}
 
-	// Setup find options
-	findOptions := options.Find()
-	findOptions.SetLimit(int64(limit))
-	findOptions.SetSkip(int64(skip))
-	findOptions.SetSort(bson.D{{Key: "rating", Value: -1}}) // Sort by rating desc
+	// Counting is skipped for cursor pages unless asked for, it costs as much
+	// as the deep pages cursors avoid
+	meta := &PaginationMeta{Page: page, Limit: limit}
+	if cursor == nil || c.Query("with_total") == "true" {
+		total, err := mgm.Coll(&models.Property{}).CountDocuments(mgm.Ctx(), searchFilter)
+		if err != nil {
+			return c.Status(500).JSON(PropertyResponse{
+				Success: false,
+				Message: "Failed to count search results",
+			})
+		}
+		meta.Total, meta.TotalPages = types.PageTotals(total, limit)
+	}
+
+	// Find properties, fetching one extra to know whether there is a next page
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: searchFilter}},
+		{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "textScore"}}}},
+	}
+	if cursor != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: cursor.Filter(sort)}})
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: cursor.QuerySort(sort)}},
+		bson.D{{Key: "$skip", Value: skip}},
+		bson.D{{Key: "$limit", Value: limit + 1}},
+	)
+	if projection := fields.Projection(sort); projection != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$project", Value: projection}})
+	}
 
-	// Get total count
-	total, err := mgm.Coll(&models.Property{}).CountDocuments(mgm.Ctx(), searchFilter)
+	properties := []models.PropertySearchResult{}
+	results, err := mgm.Coll(&models.Property{}).Aggregate(mgm.Ctx(), pipeline)
 	if err != nil {
 		return c.Status(500).JSON(PropertyResponse{
 			Success: false,
-			Message: "Failed to count search results",
+			Message: "Failed to search properties",
 		})
 	}
+	defer results.Close(mgm.Ctx())
 
-	// Find properties
-	var properties []models.Property
-	cursor, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), searchFilter, findOptions)
+	if err = results.All(mgm.Ctx(), &properties); err != nil {
+		return c.Status(500).JSON(PropertyResponse{
+			Success: false,
+			Message: "Failed to decode search results",
+		})
+	}
+
+	properties, nextCursor, prevCursor, err := services.CursorPage(properties, limit, sort, cursor, skip > 0)
 	if err != nil {
 		return c.Status(500).JSON(PropertyResponse{
 			Success: false,
-			Message: "Failed to search properties",
+			Message: "Failed to build pagination cursors",
 		})
 	}
-	defer cursor.Close(mgm.Ctx())
 
-	if err = cursor.All(mgm.Ctx(), &properties); err != nil {
+	data, err := fields.Apply(properties)
+	if err != nil {
 		return c.Status(500).JSON(PropertyResponse{
 			Success: false,
 			Message: "Failed to decode search results",
 		})
 	}
 
-	// Calculate total pages
-	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	meta.NextCursor, meta.PrevCursor = nextCursor, prevCursor
+
+	return c.JSON(PropertyResponse{
+		Success:     true,
+		Data:        data,
+		Message:     "Search completed successfully",
+		Meta:        meta,
+		Corrections: textQuery.Corrections,
+	})
+}
+
+// SuggestProperties handles GET /api/properties/suggest with prefix
+// completions for the search box
+func SuggestProperties(c *fiber.Ctx) error {
+	prefix := strings.TrimSpace(c.Query("q"))
+	if prefix == "" {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Search prefix is required",
+		})
+	}
+	if len(prefix) > services.MaxSuggestPrefix {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: fmt.Sprintf("Search prefix must be at most %d characters", services.MaxSuggestPrefix),
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", strconv.Itoa(services.DefaultSuggestLimit)))
+	if limit < 1 || limit > services.MaxSuggestLimit {
+		limit = services.DefaultSuggestLimit
+	}
+
+	var fields []string
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	suggestions, err := services.Suggest(prefix, fields, limit)
+	if err == services.ErrUnknownSuggestField {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid fields, allowed: city, state, type, amenities, tags",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(PropertyResponse{
+			Success: false,
+			Message: "Failed to fetch suggestions",
+		})
+	}
 
 	return c.JSON(PropertyResponse{
 		Success: true,
-		Data:    properties,
-		Message: "Search completed successfully",
-		Meta: &PaginationMeta{
-			Page:       page,
-			Limit:      limit,
-			Total:      total,
-			TotalPages: totalPages,
+		Data:    suggestions,
+	})
+}
+
+// parsePropertyIDs splits a comma separated ID list, dropping blanks and duplicates
+func parsePropertyIDs(param string) []string {
+	return uniquePropertyIDs(strings.Split(param, ","))
+}
+
+// uniquePropertyIDs trims the IDs and drops empty and repeated ones, keeping
+// the order they were given in
+func uniquePropertyIDs(list []string) []string {
+	ids := []string{}
+	seen := map[string]bool{}
+	for _, id := range list {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// findPropertiesByIDs returns the properties with the given IDs in the same
+// order, and the IDs no property has
+func findPropertiesByIDs(ids []string, fields *services.FieldSet) ([]models.Property, []string, error) {
+	findOptions := options.Find()
+	if projection := fields.Projection(bson.D{{Key: "id", Value: 1}}); projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
+	var found []models.Property
+	err := mgm.Coll(&models.Property{}).SimpleFind(&found, bson.M{"id": bson.M{"$in": ids}}, findOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byID := map[string]models.Property{}
+	for _, property := range found {
+		byID[property.ID] = property
+	}
+	properties := make([]models.Property, 0, len(ids))
+	missing := []string{}
+	for _, id := range ids {
+		property, ok := byID[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		properties = append(properties, property)
+	}
+	return properties, missing, nil
+}
+
+// CompareProperties handles GET /api/properties/compare
+func CompareProperties(c *fiber.Ctx) error {
+	ids := parsePropertyIDs(c.Query("ids"))
+	if len(ids) < services.MinCompareProperties || len(ids) > services.MaxCompareProperties {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: fmt.Sprintf("Between %d and %d different property IDs are required",
+				services.MinCompareProperties, services.MaxCompareProperties),
+		})
+	}
+
+	properties, missing, err := findPropertiesByIDs(ids, nil)
+	if err != nil {
+		return c.Status(500).JSON(PropertyResponse{
+			Success: false,
+			Message: "Failed to fetch properties",
+		})
+	}
+
+	if len(missing) > 0 {
+		return c.Status(404).JSON(PropertyResponse{
+			Success: false,
+			Message: "Properties not found: " + strings.Join(missing, ", "),
+		})
+	}
+
+	return c.JSON(PropertyResponse{
+		Success: true,
+		Data:    services.CompareProperties(properties),
+	})
+}
+
+// BatchGetProperties handles POST /api/properties/batch, looking up several
+// properties at once
+func BatchGetProperties(c *fiber.Ctx) error {
+	var req BatchPropertiesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+	}
+
+	ids := uniquePropertyIDs(req.IDs)
+	if len(ids) == 0 || len(ids) > maxBatchProperties {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: fmt.Sprintf("Between 1 and %d property IDs are required", maxBatchProperties),
+		})
+	}
+
+	fields, err := services.ParseFieldSet(c.Query("fields"))
+	if err != nil {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid fields: " + err.Error(),
+		})
+	}
+
+	properties, missing, err := findPropertiesByIDs(ids, fields)
+	if err != nil {
+		return c.Status(500).JSON(PropertyResponse{
+			Success: false,
+			Message: "Failed to fetch properties",
+		})
+	}
+
+	data, err := fields.Apply(properties)
+	if err != nil {
+		return c.Status(500).JSON(PropertyResponse{
+			Success: false,
+			Message: "Failed to fetch properties",
+		})
+	}
+
+	return c.JSON(PropertyResponse{
+		Success: true,
+		Data: BatchPropertiesResult{
+			Properties: data,
+			Missing:    missing,
 		},
 	})
 }
+
+// ExportProperties handles GET /api/properties/export, streaming every
+// property matching the same filters as GetProperties as a file
+func ExportProperties(c *fiber.Ctx) error {
+	format := c.Query("format", services.ExportCSV)
+	contentType, ok := services.ExportContentTypes[format]
+	if !ok {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid format: " + services.ErrUnknownExportFormat.Error(),
+		})
+	}
+
+	query, err := services.BuildPropertyFilter(c)
+	if err != nil {
+		return c.Status(400).JSON(PropertyResponse{
+			Success: false,
+			Message: "Invalid filter: " + err.Error(),
+		})
+	}
+
+	// Open the cursor up front so a failing query still gets an error response
+	export, err := services.OpenPropertyExport(query.Filter)
+	if err != nil {
+		return c.Status(500).JSON(PropertyResponse{
+			Success: false,
+			Message: "Failed to export properties",
+		})
+	}
+
+	c.Attachment("properties." + format)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		encoder, _ := services.NewPropertyEncoder(format, w)
+		if err := export.WriteTo(encoder); err != nil {
+			// The status is already sent, the client sees a truncated file
+			log.Printf("Property export failed: %v", err)
+		}
+		if err := w.Flush(); err != nil {
+			log.Printf("Property export failed: %v", err)
+		}
+	})
+	return nil
+}
+
+// GetSimilarProperties handles GET /api/properties/:id/similar
+func GetSimilarProperties(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	limit, _ := strconv.Atoi(c.Query("limit", strconv.Itoa(services.DefaultSimilarLimit)))
+	if limit < 1 || limit > services.MaxSimilarLimit {
+		limit = services.DefaultSimilarLimit
+	}
+
+	var property models.Property
+	err := mgm.Coll(&property).FindOne(mgm.Ctx(), bson.M{"id": id}).Decode(&property)
+	if err != nil {
+		return c.Status(404).JSON(PropertyResponse{
+			Success: false,
+			Message: "Property not found",
+		})
+	}
+
+	similar, err := services.FindSimilarProperties(&property, limit)
+	if err != nil {
+		return c.Status(500).JSON(PropertyResponse{
+			Success: false,
+			Message: "Failed to find similar properties",
+		})
+	}
+
+	return c.JSON(PropertyResponse{
+		Success: true,
+		Data:    similar,
+	})
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePropertyIDs(t *testing.T) {
+	tests := []struct {
+		param string
+		want  []string
+	}{
+		{"", []string{}},
+		{" , ,", []string{}},
+		{"PROP1", []string{"PROP1"}},
+		{"PROP2, PROP1 ,PROP3", []string{"PROP2", "PROP1", "PROP3"}},
+		{"PROP1,PROP2,PROP1, PROP2", []string{"PROP1", "PROP2"}},
+		// IDs are case-sensitive
+		{"PROP1,prop1", []string{"PROP1", "prop1"}},
+	}
+
+	for _, tt := range tests {
+		if got := parsePropertyIDs(tt.param); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parsePropertyIDs(%q) = %q, want %q", tt.param, got, tt.want)
+		}
+	}
+}
+
+func TestUniquePropertyIDs(t *testing.T) {
+	got := uniquePropertyIDs([]string{" PROP3", "PROP1", "", "PROP3 ", "PROP2"})
+	want := []string{"PROP3", "PROP1", "PROP2"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("uniquePropertyIDs = %q, want %q", got, want)
+	}
+}
@@ -54,6 +54,13 @@ func SendRecommendation(c *fiber.Ctx) error {
 			"message": "The recipient must be a registered user to receive recommendations",
 		})
 	}
+	if !recipient.EmailVerified {
+		log.Printf("Recipient email %s has not been verified", req.RecipientEmail)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":   "recipient email not verified",
+			"message": "The recipient must verify their email address before receiving recommendations",
+		})
+	}
 	log.Printf("Recipient email %s verified, user ID: %s", req.RecipientEmail, recipient.ID.Hex())
 
 	// Verify sender is not trying to recommend to themselves
@@ -274,3 +281,52 @@ func GetReceivedRecommendations(c *fiber.Ctx) error {
 	log.Printf("Successfully fetched %d received recommendations for user %s", len(receivedRecommendations), userID)
 	return c.Status(fiber.StatusOK).JSON(receivedRecommendations)
 }
+
+type UpdateRecommendationStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// UpdateRecommendationStatus lets the recipient accept or reject a recommendation
+func UpdateRecommendationStatus(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	userEmail := c.Locals("email").(string)
+
+	recommendationID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid recommendation id"})
+	}
+
+	var req UpdateRecommendationStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.Status != "accepted" && req.Status != "rejected" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be either accepted or rejected"})
+	}
+
+	// Only the recipient can answer a recommendation
+	recommendation := &models.Recommendation{}
+	err = mgm.Coll(recommendation).FindOne(mgm.Ctx(), bson.M{
+		"_id":             recommendationID,
+		"recipient_email": userEmail,
+	}).Decode(recommendation)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "recommendation not found"})
+	}
+
+	recommendation.Status = req.Status
+	recommendation.UpdatedAt = time.Now()
+	_, err = mgm.Coll(recommendation).UpdateOne(mgm.Ctx(), bson.M{"_id": recommendationID}, bson.M{
+		"$set": bson.M{"status": recommendation.Status, "updated_at": recommendation.UpdatedAt},
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update recommendation"})
+	}
+
+	// Refresh both sides' caches, accepted recommendations also shape the feed
+	go services.UpdateSentRecommendationsCache(recommendation.SenderID.Hex())
+	go services.UpdateReceivedRecommendationsCacheByEmail(userEmail)
+	services.InvalidateUserFeed(userID)
+
+	return c.Status(fiber.StatusOK).JSON(recommendation)
+}
@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"log"
+	"strconv"
+	"time"
+
+	"property_lister/models"
+	"property_lister/services"
+	"property_lister/types"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Maximum number of saved searches per user
+const maxSavedSearchesPerUser = 20
+
+type SavedSearchResponse struct {
+	Success bool                  `json:"success"`
+	Data    interface{}           `json:"data,omitempty"`
+	Message string                `json:"message,omitempty"`
+	Meta    *types.PaginationMeta `json:"meta,omitempty"`
+}
+
+type CreateSavedSearchRequest struct {
+	Name        string            `json:"name" validate:"required"`
+	Params      map[string]string `json:"params" validate:"required"`
+	NotifyEmail bool              `json:"notify_email"`
+}
+
+type MarkAlertsReadRequest struct {
+	IDs []string `json:"ids"` // marks every alert as read if empty
+}
+
+// CreateSavedSearch handles POST /api/saved-searches
+func CreateSavedSearch(c *fiber.Ctx) error {
+	var req CreateSavedSearchRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.Name == "" {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Name is required",
+		})
+	}
+
+	if err := services.ValidateSavedSearchParams(req.Params); err != nil {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Invalid filters: " + err.Error(),
+		})
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	count, err := mgm.Coll(&models.SavedSearch{}).CountDocuments(mgm.Ctx(), bson.M{"user_id": userObjID})
+	if err != nil {
+		return c.Status(500).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Failed to count saved searches",
+		})
+	}
+	if count >= maxSavedSearchesPerUser {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Maximum number of saved searches reached, delete one first",
+		})
+	}
+
+	now := time.Now()
+	search := &models.SavedSearch{
+		UserID:      userObjID,
+		Name:        req.Name,
+		Params:      req.Params,
+		NotifyEmail: req.NotifyEmail,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := mgm.Coll(search).Create(search); err != nil {
+		return c.Status(500).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Failed to save search",
+		})
+	}
+
+	return c.Status(201).JSON(SavedSearchResponse{
+		Success: true,
+		Message: "Search saved successfully",
+		Data:    search,
+	})
+}
+
+// GetSavedSearches handles GET /api/saved-searches
+func GetSavedSearches(c *fiber.Ctx) error {
+	userObjID, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	searches := []models.SavedSearch{}
+	cursor, err := mgm.Coll(&models.SavedSearch{}).Find(mgm.Ctx(), bson.M{
+		"user_id": userObjID,
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return c.Status(500).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Failed to fetch saved searches",
+		})
+	}
+	defer cursor.Close(mgm.Ctx())
+
+	if err = cursor.All(mgm.Ctx(), &searches); err != nil {
+		return c.Status(500).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Failed to decode saved searches",
+		})
+	}
+
+	return c.JSON(SavedSearchResponse{
+		Success: true,
+		Data:    searches,
+	})
+}
+
+// DeleteSavedSearch handles DELETE /api/saved-searches/:id
+func DeleteSavedSearch(c *fiber.Ctx) error {
+	searchObjID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Invalid saved search ID",
+		})
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	result, err := mgm.Coll(&models.SavedSearch{}).DeleteOne(mgm.Ctx(), bson.M{
+		"_id":     searchObjID,
+		"user_id": userObjID,
+	})
+	if err != nil {
+		return c.Status(500).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Failed to delete saved search",
+		})
+	}
+
+	if result.DeletedCount == 0 {
+		return c.Status(404).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Saved search not found",
+		})
+	}
+
+	// Its alerts go with it
+	_, err = mgm.Coll(&models.SearchAlert{}).DeleteMany(mgm.Ctx(), bson.M{"saved_search_id": searchObjID})
+	if err != nil {
+		log.Printf("Failed to delete alerts of saved search %s: %v", searchObjID.Hex(), err)
+	}
+
+	return c.JSON(SavedSearchResponse{
+		Success: true,
+		Message: "Saved search deleted successfully",
+	})
+}
+
+// GetSearchAlerts handles GET /api/saved-searches/alerts
+func GetSearchAlerts(c *fiber.Ctx) error {
+	userObjID, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	// Parse pagination
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := bson.M{"user_id": userObjID}
+	if c.Query("unread") == "true" {
+		filter["read"] = false
+	}
+
+	total, err := mgm.Coll(&models.SearchAlert{}).CountDocuments(mgm.Ctx(), filter)
+	if err != nil {
+		return c.Status(500).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Failed to count alerts",
+		})
+	}
+
+	findOptions := options.Find()
+	findOptions.SetLimit(int64(limit))
+	findOptions.SetSkip(int64((page - 1) * limit))
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}) // Newest first
+
+	alerts := []models.SearchAlert{}
+	cursor, err := mgm.Coll(&models.SearchAlert{}).Find(mgm.Ctx(), filter, findOptions)
+	if err != nil {
+		return c.Status(500).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Failed to fetch alerts",
+		})
+	}
+	defer cursor.Close(mgm.Ctx())
+
+	if err = cursor.All(mgm.Ctx(), &alerts); err != nil {
+		return c.Status(500).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Failed to decode alerts",
+		})
+	}
+
+	meta := &types.PaginationMeta{Page: page, Limit: limit}
+	meta.Total, meta.TotalPages = types.PageTotals(total, limit)
+
+	return c.JSON(SavedSearchResponse{
+		Success: true,
+		Data:    alerts,
+		Meta:    meta,
+	})
+}
+
+// MarkSearchAlertsRead handles POST /api/saved-searches/alerts/read
+func MarkSearchAlertsRead(c *fiber.Ctx) error {
+	var req MarkAlertsReadRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(SavedSearchResponse{
+				Success: false,
+				Message: "Invalid request body",
+			})
+		}
+	}
+
+	userObjID, err := primitive.ObjectIDFromHex(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(400).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	filter := bson.M{"user_id": userObjID, "read": false}
+	if len(req.IDs) > 0 {
+		alertIDs := make([]primitive.ObjectID, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			alertID, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				return c.Status(400).JSON(SavedSearchResponse{
+					Success: false,
+					Message: "Invalid alert ID: " + id,
+				})
+			}
+			alertIDs = append(alertIDs, alertID)
+		}
+		filter["_id"] = bson.M{"$in": alertIDs}
+	}
+
+	result, err := mgm.Coll(&models.SearchAlert{}).UpdateMany(mgm.Ctx(), filter, bson.M{
+		"$set": bson.M{"read": true, "updated_at": time.Now()},
+	})
+	if err != nil {
+		return c.Status(500).JSON(SavedSearchResponse{
+			Success: false,
+			Message: "Failed to mark alerts as read",
+		})
+	}
+
+	return c.JSON(SavedSearchResponse{
+		Success: true,
+		Message: "Alerts marked as read",
+		Data:    fiber.Map{"updated": result.ModifiedCount},
+	})
+}
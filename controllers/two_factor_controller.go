@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	"time"
+
+	"property_lister/models"
+	"property_lister/services"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+}
+
+type TwoFactorVerifyRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisableRequest struct {
+	Password     string `json:"password" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type TwoFactorSetupData struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+}
+
+type TwoFactorRecoveryCodesData struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// checkSecondFactor validates either a TOTP code or a recovery code for the
+// user. A matching recovery code is consumed.
+func checkSecondFactor(user *models.User, code, recoveryCode string) bool {
+	if code != "" {
+		step, ok := services.ValidateTOTP(user.TwoFactorSecret, code, time.Now())
+		return ok && services.MarkTOTPStepUsed(user.ID.Hex(), step)
+	}
+
+	if recoveryCode != "" {
+		// $pull only matches if the hashed code is still unused
+		codeHash := services.HashRecoveryCode(recoveryCode)
+		result, err := mgm.Coll(user).UpdateOne(mgm.Ctx(),
+			bson.M{"_id": user.ID, "recovery_codes": codeHash},
+			bson.M{"$pull": bson.M{"recovery_codes": codeHash}},
+		)
+		return err == nil && result.ModifiedCount == 1
+	}
+
+	return false
+}
+
+// LoginTwoFactor handles POST /api/auth/login/2fa, the second step of the
+// login for accounts with two-factor authentication enabled
+func LoginTwoFactor(c *fiber.Ctx) error {
+	var req TwoFactorLoginRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.ChallengeToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Challenge token and a code or recovery code are required",
+		})
+	}
+
+	claims, err := services.ParseToken(req.ChallengeToken, services.TokenTypeTwoFactorChallenge)
+	if err != nil {
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid or expired challenge token",
+		})
+	}
+
+	// Code guessing counts towards the same lockout as password guessing
+	if wait := services.CheckLoginAllowed(claims.Email, c.IP()); wait > 0 {
+		return tooManyLoginAttempts(c, wait)
+	}
+
+	objID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid or expired challenge token",
+		})
+	}
+
+	var user models.User
+	err = mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": objID}).Decode(&user)
+	if err != nil || !user.TwoFactorEnabled {
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid or expired challenge token",
+		})
+	}
+
+	if !checkSecondFactor(&user, req.Code, req.RecoveryCode) {
+		services.RecordLoginFailure(user.Email, c.IP())
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+		})
+	}
+
+	// Challenge tokens are single use
+	services.RevokeToken(claims)
+	services.RecordLoginSuccess(user.Email)
+
+	return completeLogin(c, &user)
+}
+
+// SetupTwoFactor handles POST /api/me/2fa/setup. The secret only becomes
+// active once a code generated from it is confirmed via VerifyTwoFactor.
+func SetupTwoFactor(c *fiber.Ctx) error {
+	user, err := findCurrentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(ProfileResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	if user.TwoFactorEnabled {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Two-factor authentication is already enabled",
+		})
+	}
+
+	secret, err := services.GenerateTOTPSecret()
+	if err != nil {
+		return c.Status(500).JSON(ProfileResponse{
+			Success: false,
+			Message: "Failed to generate secret",
+		})
+	}
+
+	_, err = mgm.Coll(user).UpdateOne(mgm.Ctx(), bson.M{"_id": user.ID}, bson.M{
+		"$set": bson.M{"two_factor_pending_secret": secret, "updated_at": time.Now()},
+	})
+	if err != nil {
+		return c.Status(500).JSON(ProfileResponse{
+			Success: false,
+			Message: "Failed to start two-factor setup",
+		})
+	}
+
+	return c.JSON(ProfileResponse{
+		Success: true,
+		Message: "Scan the QR code with your authenticator app and confirm with a code",
+		Data: TwoFactorSetupData{
+			Secret:     secret,
+			OTPAuthURL: services.TOTPProvisioningURI(secret, user.Email),
+		},
+	})
+}
+
+// VerifyTwoFactor handles POST /api/me/2fa/verify, enabling two-factor
+// authentication and returning the recovery codes once
+func VerifyTwoFactor(c *fiber.Ctx) error {
+	var req TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Code is required",
+		})
+	}
+
+	user, err := findCurrentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(ProfileResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	if user.TwoFactorEnabled {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Two-factor authentication is already enabled",
+		})
+	}
+
+	if user.TwoFactorPendingSecret == "" {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Start two-factor setup first",
+		})
+	}
+
+	step, ok := services.ValidateTOTP(user.TwoFactorPendingSecret, req.Code, time.Now())
+	if !ok || !services.MarkTOTPStepUsed(user.ID.Hex(), step) {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+		})
+	}
+
+	codes, hashes, err := services.GenerateRecoveryCodes()
+	if err != nil {
+		return c.Status(500).JSON(ProfileResponse{
+			Success: false,
+			Message: "Failed to generate recovery codes",
+		})
+	}
+
+	_, err = mgm.Coll(user).UpdateOne(mgm.Ctx(), bson.M{"_id": user.ID}, bson.M{
+		"$set": bson.M{
+			"two_factor_enabled": true,
+			"two_factor_secret":  user.TwoFactorPendingSecret,
+			"recovery_codes":     hashes,
+			"updated_at":         time.Now(),
+		},
+		"$unset": bson.M{"two_factor_pending_secret": ""},
+	})
+	if err != nil {
+		return c.Status(500).JSON(ProfileResponse{
+			Success: false,
+			Message: "Failed to enable two-factor authentication",
+		})
+	}
+
+	services.DeleteCache(services.GetCacheKey("user_profile", user.ID.Hex(), ""))
+
+	return c.JSON(ProfileResponse{
+		Success: true,
+		Message: "Two-factor authentication enabled. Store these recovery codes somewhere safe, they won't be shown again",
+		Data:    TwoFactorRecoveryCodesData{RecoveryCodes: codes},
+	})
+}
+
+// DisableTwoFactor handles POST /api/me/2fa/disable and requires both the
+// password and a current code or recovery code. Failures count towards the
+// login lockout, so a stolen access token can't be used to guess either.
+func DisableTwoFactor(c *fiber.Ctx) error {
+	var req TwoFactorDisableRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
+	}
+
+	if req.Password == "" || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Password and a code or recovery code are required",
+		})
+	}
+
+	user, err := findCurrentUser(c)
+	if err != nil {
+		return c.Status(404).JSON(ProfileResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	if !user.TwoFactorEnabled {
+		return c.Status(400).JSON(ProfileResponse{
+			Success: false,
+			Message: "Two-factor authentication is not enabled",
+		})
+	}
+
+	if wait := services.CheckLoginAllowed(user.Email, c.IP()); wait > 0 {
+		return tooManyLoginAttempts(c, wait)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		services.RecordLoginFailure(user.Email, c.IP())
+		return c.Status(401).JSON(ProfileResponse{
+			Success: false,
+			Message: "Password is incorrect",
+		})
+	}
+
+	if !checkSecondFactor(user, req.Code, req.RecoveryCode) {
+		services.RecordLoginFailure(user.Email, c.IP())
+		return c.Status(401).JSON(ProfileResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+		})
+	}
+	services.RecordLoginSuccess(user.Email)
+
+	_, err = mgm.Coll(user).UpdateOne(mgm.Ctx(), bson.M{"_id": user.ID}, bson.M{
+		"$set": bson.M{"two_factor_enabled": false, "updated_at": time.Now()},
+		"$unset": bson.M{
+			"two_factor_secret":         "",
+			"two_factor_pending_secret": "",
+			"recovery_codes":            "",
+		},
+	})
+	if err != nil {
+		return c.Status(500).JSON(ProfileResponse{
+			Success: false,
+			Message: "Failed to disable two-factor authentication",
+		})
+	}
+
+	services.DeleteCache(services.GetCacheKey("user_profile", user.ID.Hex(), ""))
+
+	return c.JSON(ProfileResponse{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	})
+}
@@ -1,16 +1,18 @@
 package controllers
 
 import (
-	"os"
+	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"property_lister/models"
 	"property_lister/services"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/kamva/mgm/v3"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -28,14 +30,34 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
 // Response structures (belongs in presentation layer)
 type UserResponse struct {
-	ID        string    `json:"id"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Phone     string    `json:"phone"`
-	CreatedAt time.Time `json:"created_at"`
+	ID               string    `json:"id"`
+	Email            string    `json:"email"`
+	FirstName        string    `json:"first_name"`
+	LastName         string    `json:"last_name"`
+	Phone            string    `json:"phone"`
+	Role             string    `json:"role"`
+	EmailVerified    bool      `json:"email_verified"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
 }
 
 type AuthResponse struct {
@@ -45,19 +67,46 @@ type AuthResponse struct {
 }
 
 type AuthData struct {
-	User  UserResponse `json:"user"`
-	Token string       `json:"token"`
+	User         UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresIn    int64        `json:"expires_in"`
+}
+
+type TwoFactorChallengeResponse struct {
+	Success bool                    `json:"success"`
+	Message string                  `json:"message,omitempty"`
+	Data    *TwoFactorChallengeData `json:"data,omitempty"`
+}
+
+type TwoFactorChallengeData struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"`
+}
+
+// toAuthData bundles the user and a freshly issued token pair
+func toAuthData(user *models.User, tokens *services.TokenPair) *AuthData {
+	return &AuthData{
+		User:         toUserResponse(user),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}
 }
 
 // toUserResponse converts User model to UserResponse DTO
 func toUserResponse(user *models.User) UserResponse {
 	return UserResponse{
-		ID:        user.ID.Hex(),
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Phone:     user.Phone,
-		CreatedAt: user.CreatedAt,
+		ID:               user.ID.Hex(),
+		Email:            user.Email,
+		FirstName:        user.FirstName,
+		LastName:         user.LastName,
+		Phone:            user.Phone,
+		Role:             user.GetRole(),
+		EmailVerified:    user.EmailVerified,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		CreatedAt:        user.CreatedAt,
 	}
 }
 
@@ -103,13 +152,15 @@ func RegisterUser(c *fiber.Ctx) error {
 	}
 
 	user := &models.User{
-		Email:     req.Email,
-		Password:  string(hashedPassword),
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Phone:     req.Phone,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Email:         req.Email,
+		Password:      string(hashedPassword),
+		FirstName:     req.FirstName,
+		LastName:      req.LastName,
+		Phone:         req.Phone,
+		Role:          models.RoleOwner, // other roles are granted by an admin
+		EmailVerified: false,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
 	}
 
 	if err := mgm.Coll(user).Create(user); err != nil {
@@ -119,7 +170,7 @@ func RegisterUser(c *fiber.Ctx) error {
 		})
 	}
 
-	token, err := generateJWTToken(user.ID.Hex(), user.Email)
+	tokens, err := services.GenerateTokenPair(user)
 	if err != nil {
 		return c.Status(500).JSON(AuthResponse{
 			Success: false,
@@ -127,13 +178,12 @@ func RegisterUser(c *fiber.Ctx) error {
 		})
 	}
 
+	go sendVerificationEmail(user)
+
 	return c.Status(201).JSON(AuthResponse{
 		Success: true,
-		Message: "User registered successfully",
-		Data: &AuthData{
-			User:  toUserResponse(user),
-			Token: token,
-		},
+		Message: "User registered successfully. Please check your email to verify your account",
+		Data:    toAuthData(user, tokens),
 	})
 }
 
@@ -154,9 +204,15 @@ func LoginUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Reject attempts while the account or IP is locked out or delayed
+	if wait := services.CheckLoginAllowed(req.Email, c.IP()); wait > 0 {
+		return tooManyLoginAttempts(c, wait)
+	}
+
 	var user models.User
 	err := mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"email": req.Email}).Decode(&user)
 	if err != nil {
+		services.RecordLoginFailure(req.Email, c.IP())
 		return c.Status(401).JSON(AuthResponse{
 			Success: false,
 			Message: "Invalid email or password",
@@ -165,13 +221,43 @@ func LoginUser(c *fiber.Ctx) error {
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
+		services.RecordLoginFailure(req.Email, c.IP())
 		return c.Status(401).JSON(AuthResponse{
 			Success: false,
 			Message: "Invalid email or password",
 		})
 	}
 
-	token, err := generateJWTToken(user.ID.Hex(), user.Email)
+	// Accounts with two-factor authentication need a second step before
+	// any tokens are issued
+	if user.TwoFactorEnabled {
+		challenge, err := services.GenerateTwoFactorChallenge(&user)
+		if err != nil {
+			return c.Status(500).JSON(AuthResponse{
+				Success: false,
+				Message: "Failed to generate token",
+			})
+		}
+
+		return c.JSON(TwoFactorChallengeResponse{
+			Success: true,
+			Message: "Two-factor authentication required",
+			Data: &TwoFactorChallengeData{
+				TwoFactorRequired: true,
+				ChallengeToken:    challenge,
+				ExpiresIn:         int64(services.TwoFactorChallengeTTL.Seconds()),
+			},
+		})
+	}
+
+	services.RecordLoginSuccess(req.Email)
+
+	return completeLogin(c, &user)
+}
+
+// completeLogin issues tokens for an authenticated user and warms their cache
+func completeLogin(c *fiber.Ctx, user *models.User) error {
+	tokens, err := services.GenerateTokenPair(user)
 	if err != nil {
 		return c.Status(500).JSON(AuthResponse{
 			Success: false,
@@ -181,7 +267,7 @@ func LoginUser(c *fiber.Ctx) error {
 
 	// Update user last login time
 	user.UpdatedAt = time.Now()
-	mgm.Coll(&user).UpdateOne(mgm.Ctx(), bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"updated_at": user.UpdatedAt}})
+	mgm.Coll(user).UpdateOne(mgm.Ctx(), bson.M{"_id": user.ID}, bson.M{"$set": bson.M{"updated_at": user.UpdatedAt}})
 
 	// Load and cache user data on login
 	go services.CacheUserDataOnLogin(user.ID.Hex(), user.Email)
@@ -189,29 +275,348 @@ func LoginUser(c *fiber.Ctx) error {
 	return c.JSON(AuthResponse{
 		Success: true,
 		Message: "Login successful",
-		Data: &AuthData{
-			User:  toUserResponse(&user),
-			Token: token,
-		},
+		Data:    toAuthData(user, tokens),
 	})
 }
 
-func generateJWTToken(userID, email string) (string, error) {
-	jwtSecret := os.Getenv("JWT_SECRET")
+// tooManyLoginAttempts responds with 429 and a Retry-After header in seconds
+func tooManyLoginAttempts(c *fiber.Ctx, wait time.Duration) error {
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return c.Status(429).JSON(AuthResponse{
+		Success: false,
+		Message: fmt.Sprintf("Too many failed login attempts. Try again in %d seconds", retryAfter),
+	})
+}
+
+// RefreshToken handles POST /api/auth/refresh by rotating the refresh token
+func RefreshToken(c *fiber.Ctx) error {
+	var req RefreshTokenRequest
+
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Refresh token is required",
+		})
+	}
+
+	claims, err := services.ParseToken(req.RefreshToken, services.TokenTypeRefresh)
+	if err != nil {
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid or expired refresh token",
+		})
+	}
 
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"email":   email,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(), // 7 days
-		"iat":     time.Now().Unix(),
+	// Make sure the account still exists before issuing new tokens
+	objID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid or expired refresh token",
+		})
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	var user models.User
+	err = mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": objID}).Decode(&user)
+	if err != nil {
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "User no longer exists",
+		})
+	}
 
-	tokenString, err := token.SignedString([]byte(jwtSecret))
+	tokens, err := services.RotateRefreshToken(claims, &user)
+	if err == services.ErrRefreshTokenReuse {
+		return c.Status(401).JSON(AuthResponse{
+			Success: false,
+			Message: "Refresh token has already been used, all sessions have been revoked",
+		})
+	}
 	if err != nil {
-		return "", err
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to refresh token",
+		})
+	}
+
+	return c.JSON(AuthResponse{
+		Success: true,
+		Message: "Token refreshed successfully",
+		Data:    toAuthData(&user, tokens),
+	})
+}
+
+// LogoutUser handles POST /api/auth/logout by revoking the current access
+// token and, if supplied, the refresh token of the same session
+func LogoutUser(c *fiber.Ctx) error {
+	claims := c.Locals("token_claims").(*services.TokenClaims)
+
+	if err := services.RevokeToken(claims); err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to logout",
+		})
+	}
+
+	var req LogoutRequest
+	if err := c.BodyParser(&req); err == nil && req.RefreshToken != "" {
+		refreshClaims, err := services.ParseToken(req.RefreshToken, services.TokenTypeRefresh)
+		if err == nil && refreshClaims.UserID == claims.UserID {
+			services.RevokeToken(refreshClaims)
+		}
+	}
+
+	return c.JSON(AuthResponse{
+		Success: true,
+		Message: "Logged out successfully",
+	})
+}
+
+// LogoutAllSessions handles POST /api/auth/logout-all by revoking every
+// token issued to the user
+func LogoutAllSessions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	if err := services.RevokeAllUserTokens(userID); err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to logout from all sessions",
+		})
+	}
+
+	return c.JSON(AuthResponse{
+		Success: true,
+		Message: "Logged out from all sessions successfully",
+	})
+}
+
+// ForgotPassword handles POST /api/auth/forgot-password. The response is the
+// same whether or not the email belongs to an account.
+func ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Email is required",
+		})
+	}
+
+	var user models.User
+	err := mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"email": req.Email}).Decode(&user)
+	if err == nil {
+		token, err := services.CreatePasswordResetToken(user.ID.Hex())
+		if err != nil {
+			return c.Status(500).JSON(AuthResponse{
+				Success: false,
+				Message: "Failed to create reset token",
+			})
+		}
+
+		go services.SendMail(services.Mail{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf(
+				"Hi %s,\n\nUse the link below to reset your password. It expires in %d minutes and can only be used once.\n\n%s\n\nIf you didn't request this, you can ignore this email.\n",
+				user.FirstName,
+				int(services.PasswordResetTTL.Minutes()),
+				services.AppURL("/reset-password?token="+token),
+			),
+		})
+	}
+
+	return c.JSON(AuthResponse{
+		Success: true,
+		Message: "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword handles POST /api/auth/reset-password
+func ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid request body",
+		})
 	}
 
-	return tokenString, nil
+	if req.Token == "" || req.Password == "" {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Token and password are required",
+		})
+	}
+
+	if len(req.Password) < 6 {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Password must be at least 6 characters long",
+		})
+	}
+
+	userID, err := services.ConsumePasswordResetToken(req.Token)
+	if err == services.ErrInvalidResetToken {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid or expired reset token",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to verify reset token",
+		})
+	}
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid or expired reset token",
+		})
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to hash password",
+		})
+	}
+
+	var user models.User
+	err = mgm.Coll(&user).FindOneAndUpdate(mgm.Ctx(), bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{"password": string(hashedPassword), "updated_at": time.Now()},
+	}).Decode(&user)
+	if err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to reset password",
+		})
+	}
+
+	// Sessions opened with the old password are no longer trusted, and a
+	// successful reset lifts any login lockout
+	services.RevokeAllUserTokens(userID)
+	services.UnlockAccount(user.Email)
+
+	return c.JSON(AuthResponse{
+		Success: true,
+		Message: "Password reset successfully",
+	})
+}
+
+// sendVerificationEmail emails the user a link to confirm their address
+func sendVerificationEmail(user *models.User) error {
+	token, err := services.CreateEmailVerificationToken(user.ID.Hex())
+	if err != nil {
+		return err
+	}
+
+	return services.SendMail(services.Mail{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %d hours.\n\n%s\n",
+			user.FirstName,
+			int(services.EmailVerificationTTL.Hours()),
+			services.AppURL("/api/auth/verify?token="+token),
+		),
+	})
+}
+
+// VerifyEmail handles GET /api/auth/verify?token=...
+func VerifyEmail(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Verification token is required",
+		})
+	}
+
+	userID, err := services.ConsumeEmailVerificationToken(token)
+	if err == services.ErrInvalidVerificationToken {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid or expired verification token",
+		})
+	}
+	if err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to verify email",
+		})
+	}
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid or expired verification token",
+		})
+	}
+
+	now := time.Now()
+	result, err := mgm.Coll(&models.User{}).UpdateOne(mgm.Ctx(), bson.M{"_id": objID}, bson.M{
+		"$set": bson.M{"email_verified": true, "email_verified_at": now, "updated_at": now},
+	})
+	if err != nil || result.MatchedCount == 0 {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to verify email",
+		})
+	}
+
+	// Drop the cached profile so it reflects the verified state
+	services.DeleteCache(services.GetCacheKey("user_profile", userID, ""))
+
+	return c.JSON(AuthResponse{
+		Success: true,
+		Message: "Email verified successfully. Refresh your token or log in again to unlock all features",
+	})
+}
+
+// ResendVerificationEmail handles POST /api/auth/resend-verification
+func ResendVerificationEmail(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Invalid user ID",
+		})
+	}
+
+	var user models.User
+	err = mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": objID}).Decode(&user)
+	if err != nil {
+		return c.Status(404).JSON(AuthResponse{
+			Success: false,
+			Message: "User not found",
+		})
+	}
+
+	if user.EmailVerified {
+		return c.Status(400).JSON(AuthResponse{
+			Success: false,
+			Message: "Email is already verified",
+		})
+	}
+
+	if err := sendVerificationEmail(&user); err != nil {
+		return c.Status(500).JSON(AuthResponse{
+			Success: false,
+			Message: "Failed to send verification email",
+		})
+	}
+
+	return c.JSON(AuthResponse{
+		Success: true,
+		Message: "Verification email sent",
+	})
 }
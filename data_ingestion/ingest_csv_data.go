@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"property_lister/models"
+	"property_lister/services"
 
 	"github.com/kamva/mgm/v3"
 )
@@ -43,6 +44,11 @@ func IngestCSV(csvPath string) error {
 		bath, _ := strconv.Atoi(row[8])
 		rating, _ := strconv.ParseFloat(row[15], 64)
 		isVerified := row[16] == "True"
+		availableFrom, err := models.ParseDate(row[11])
+		if err != nil {
+			fmt.Printf("Skipping row %d: invalid availableFrom: %v\n", i, err)
+			continue
+		}
 
 		now := time.Now()
 
@@ -58,13 +64,14 @@ func IngestCSV(csvPath string) error {
 			Bathrooms:     bath,
 			Amenities:     strings.Split(row[9], "|"),
 			Furnished:     row[10],
-			AvailableFrom: row[11],
+			AvailableFrom: availableFrom,
 			ListedBy:      row[12],
 			Tags:          strings.Split(row[13], "|"),
 			ColorTheme:    row[14],
 			Rating:        rating,
 			IsVerified:    isVerified,
 			ListingType:   row[17],
+			Location:      services.LookupCityLocation(row[5], row[4]),
 			CreatedBy:     "SYSTEM",
 			CreatedAt:     now,
 			UpdatedAt:     now,
@@ -77,5 +84,10 @@ func IngestCSV(csvPath string) error {
 		}
 	}
 
+	// Ingested values show up in search suggestions
+	if err := services.RebuildSuggestions(); err != nil {
+		return fmt.Errorf("failed to rebuild search suggestions: %w", err)
+	}
+
 	return nil
 }
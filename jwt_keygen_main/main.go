@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+func main() {
+	dir := flag.String("dir", "keys", "directory to write the key to")
+	kid := flag.String("kid", time.Now().Format("20060102-150405"), "key id, used as the file name")
+	alg := flag.String("alg", "EdDSA", "signing algorithm: EdDSA or RS256")
+	flag.Parse()
+
+	var privateKey interface{}
+	var err error
+	switch *alg {
+	case "EdDSA":
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	case "RS256":
+		privateKey, err = rsa.GenerateKey(rand.Reader, 3072)
+	default:
+		log.Fatalf("Unsupported algorithm %q, use EdDSA or RS256", *alg)
+	}
+	if err != nil {
+		log.Fatalf("Failed to generate key: %v", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		log.Fatalf("Failed to encode key: %v", err)
+	}
+
+	if err := os.MkdirAll(*dir, 0o700); err != nil {
+		log.Fatalf("Failed to create key directory: %v", err)
+	}
+
+	path := filepath.Join(*dir, *kid+".pem")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.Fatalf("Failed to create key file: %v", err)
+	}
+	defer file.Close()
+
+	if err := pem.Encode(file, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		log.Fatalf("Failed to write key file: %v", err)
+	}
+
+	log.Printf("Wrote %s key '%s' to %s", *alg, *kid, path)
+}
@@ -6,6 +6,7 @@ import (
 
 	"property_lister/config"
 	"property_lister/routes"
+	"property_lister/services"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -32,9 +33,23 @@ func main() {
 		log.Fatal("Failed to connect to MongoDB:", err)
 	}
 
+	// Make sure the indexes queries depend on exist
+	if err := services.EnsureIndexes(); err != nil {
+		log.Fatal("Failed to create MongoDB indexes:", err)
+	}
+
+	// Build the search suggestions in case properties changed while down
+	go services.RefreshSuggestions()
+
 	// Initialize Redis
 	config.InitRedis()
 
+	// Load JWT signing and verification keys
+	config.InitJWTKeys()
+
+	// Initialize outgoing mail
+	services.InitMailer()
+
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
@@ -55,7 +70,7 @@ func main() {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowMethods: "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowHeaders: "Origin,Content-Type,Accept,Authorization,X-API-Key",
 	}))
 
 	// Health check endpoint
@@ -67,11 +82,17 @@ func main() {
 	})
 
 	// Setup routes
+	routes.SetupWellKnownRoutes(app)
 	routes.SetupUserRoutes(app)
+	routes.SetupMeRoutes(app)
 	routes.SetupPropertyRoutes(app)
 	routes.SetupListingRoutes(app)
 	routes.SetupFavoriteRoutes(app)
 	routes.SetupRecommendationRoutes(app)
+	routes.SetupSavedSearchRoutes(app)
+	routes.SetupFeedRoutes(app)
+	routes.SetupAPIKeyRoutes(app)
+	routes.SetupAdminRoutes(app)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"property_lister/models"
+	"property_lister/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuthOrAPIKey authenticates the request with an X-API-Key header if one is
+// present and falls back to AuthMiddleware otherwise. Scopes of API keys are
+// enforced per route with RequireScope.
+func AuthOrAPIKey() fiber.Handler {
+	jwtAuth := AuthMiddleware()
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get("X-API-Key")
+		if key == "" {
+			return jwtAuth(c)
+		}
+		return authenticateAPIKey(c, key)
+	}
+}
+
+// OptionalAPIKey authenticates the request with an X-API-Key header if one is
+// present and lets anonymous requests through, for public routes that still
+// check the scopes of the keys presented to them
+func OptionalAPIKey() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get("X-API-Key")
+		if key == "" {
+			return c.Next()
+		}
+		return authenticateAPIKey(c, key)
+	}
+}
+
+func authenticateAPIKey(c *fiber.Ctx, key string) error {
+	apiKey, user, err := services.AuthenticateAPIKey(key)
+	if err != nil {
+		return c.Status(401).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid, expired or revoked API key",
+		})
+	}
+
+	// Add user information to context
+	c.Locals("user_id", user.ID.Hex())
+	c.Locals("email", user.Email)
+	c.Locals("role", user.GetRole())
+	c.Locals("email_verified", user.EmailVerified)
+	c.Locals("api_key", apiKey)
+	return c.Next()
+}
+
+// RequireScope rejects API key requests whose key wasn't granted the scope.
+// Requests authenticated with a user JWT, or not at all, are always let
+// through.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		apiKey, ok := c.Locals("api_key").(*models.APIKey)
+		if !ok || apiKey.HasScope(scope) {
+			return c.Next()
+		}
+
+		return c.Status(403).JSON(fiber.Map{
+			"success": false,
+			"message": "API key is missing the '" + scope + "' scope",
+		})
+	}
+}
@@ -1,11 +1,11 @@
 package middleware
 
 import (
-	"os"
 	"strings"
 
+	"property_lister/services"
+
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // AuthMiddleware verifies JWT token and extracts user information
@@ -31,21 +31,14 @@ func AuthMiddleware() fiber.Handler {
 			})
 		}
 
-		// Get JWT secret from environment
-		jwtSecret := os.Getenv("JWT_SECRET")
-		if jwtSecret == "" {
-			jwtSecret = "your-secret-key" // Default secret (change in production)
+		// Parse and validate token, including revocation checks
+		claims, err := services.ParseToken(tokenString, services.TokenTypeAccess)
+		if err == services.ErrTokenRevoked {
+			return c.Status(401).JSON(fiber.Map{
+				"success": false,
+				"message": "Token has been revoked",
+			})
 		}
-
-		// Parse and validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Validate signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fiber.NewError(401, "Invalid signing method")
-			}
-			return []byte(jwtSecret), nil
-		})
-
 		if err != nil {
 			return c.Status(401).JSON(fiber.Map{
 				"success": false,
@@ -53,17 +46,12 @@ func AuthMiddleware() fiber.Handler {
 			})
 		}
 
-		// Extract claims
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Add user information to context
-			c.Locals("user_id", claims["user_id"])
-			c.Locals("email", claims["email"])
-			return c.Next()
-		}
-
-		return c.Status(401).JSON(fiber.Map{
-			"success": false,
-			"message": "Invalid token claims",
-		})
+		// Add user information to context
+		c.Locals("user_id", claims.UserID)
+		c.Locals("email", claims.Email)
+		c.Locals("role", claims.Role)
+		c.Locals("email_verified", claims.EmailVerified)
+		c.Locals("token_claims", claims)
+		return c.Next()
 	}
 }
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireRole allows the request through only if the authenticated user has
+// one of the given roles. It must run after AuthMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		for _, allowed := range roles {
+			if role == allowed {
+				return c.Next()
+			}
+		}
+
+		return c.Status(403).JSON(fiber.Map{
+			"success": false,
+			"message": "You don't have permission to access this resource",
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireVerifiedEmail rejects requests from users who haven't confirmed
+// their email address yet. It must run after AuthMiddleware.
+func RequireVerifiedEmail() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if verified, _ := c.Locals("email_verified").(bool); verified {
+			return c.Next()
+		}
+
+		return c.Status(403).JSON(fiber.Map{
+			"success": false,
+			"message": "Please verify your email address to use this feature",
+		})
+	}
+}
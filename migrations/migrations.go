@@ -0,0 +1,31 @@
+package migrations
+
+import (
+	"fmt"
+	"log"
+)
+
+// Migration is a one-off data migration. Every migration must be idempotent
+// so the whole list can safely be run again.
+type Migration struct {
+	Name string
+	Run  func() error
+}
+
+// All lists the migrations in the order they must be applied
+var All = []Migration{
+	{Name: "backfill_email_verified", Run: BackfillEmailVerified},
+	{Name: "backfill_property_locations", Run: BackfillPropertyLocations},
+	{Name: "convert_available_from_to_date", Run: ConvertAvailableFromToDate},
+}
+
+// RunAll applies every migration in order and stops at the first failure
+func RunAll() error {
+	for _, m := range All {
+		log.Printf("Running migration %s", m.Name)
+		if err := m.Run(); err != nil {
+			return fmt.Errorf("migration %s failed: %w", m.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"log"
+
+	"property_lister/models"
+	"property_lister/services"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BackfillPropertyLocations sets the location of properties stored before
+// locations existed, looked up from their city in the gazetteer
+func BackfillPropertyLocations() error {
+	var properties []models.Property
+	err := mgm.Coll(&models.Property{}).SimpleFind(&properties, bson.M{
+		"location": bson.M{"$exists": false},
+	})
+	if err != nil {
+		return err
+	}
+
+	updated, unknown := 0, map[string]bool{}
+	for _, property := range properties {
+		location := services.LookupCityLocation(property.City, property.State)
+		if location == nil {
+			unknown[property.City+", "+property.State] = true
+			continue
+		}
+
+		_, err := mgm.Coll(&property).UpdateOne(mgm.Ctx(), bson.M{"_id": property.DefaultModel.ID}, bson.M{
+			"$set": bson.M{"location": location},
+		})
+		if err != nil {
+			return err
+		}
+		updated++
+	}
+
+	for city := range unknown {
+		log.Printf("No gazetteer entry for %s, properties there stay without a location", city)
+	}
+	log.Printf("Set the location of %d properties", updated)
+	return nil
+}
+
+// ConvertAvailableFromToDate turns the YYYY-MM-DD strings stored in
+// availableFrom into real dates. Values that aren't valid dates are cleared.
+func ConvertAvailableFromToDate() error {
+	result, err := mgm.Coll(&models.Property{}).UpdateMany(
+		mgm.Ctx(),
+		bson.M{"availableFrom": bson.M{"$type": "string"}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"availableFrom": bson.M{"$dateFromString": bson.M{
+				"dateString": "$availableFrom",
+				"format":     "%Y-%m-%d",
+				"timezone":   "UTC",
+				"onError":    nil,
+				"onNull":     nil,
+			}}}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	invalid, err := mgm.Coll(&models.Property{}).CountDocuments(mgm.Ctx(), bson.M{"availableFrom": nil})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Converted availableFrom of %d properties, %d have no valid date", result.ModifiedCount, invalid)
+	return nil
+}
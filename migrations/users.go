@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"log"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// BackfillEmailVerified marks accounts created before email verification
+// existed as verified so they keep their current access
+func BackfillEmailVerified() error {
+	result, err := mgm.Coll(&models.User{}).UpdateMany(
+		mgm.Ctx(),
+		bson.M{"email_verified": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"email_verified": true}},
+	)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Marked %d existing users as verified", result.ModifiedCount)
+	return nil
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"log"
+
+	"property_lister/config"
+	"property_lister/migrations"
+)
+
+func main() {
+	config.InitMongo()
+
+	if err := migrations.RunAll(); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	log.Println("Successfully applied all migrations")
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// API key scopes
+const (
+	ScopePropertiesRead       = "properties:read"
+	ScopeListingsRead         = "listings:read"
+	ScopeListingsWrite        = "listings:write"
+	ScopeFavoritesRead        = "favorites:read"
+	ScopeFavoritesWrite       = "favorites:write"
+	ScopeRecommendationsRead  = "recommendations:read"
+	ScopeRecommendationsWrite = "recommendations:write"
+)
+
+// APIKeyScopes lists every scope an API key can be granted
+var APIKeyScopes = []string{
+	ScopePropertiesRead,
+	ScopeListingsRead,
+	ScopeListingsWrite,
+	ScopeFavoritesRead,
+	ScopeFavoritesWrite,
+	ScopeRecommendationsRead,
+	ScopeRecommendationsWrite,
+}
+
+type APIKey struct {
+	mgm.DefaultModel `bson:",inline"`
+
+	UserID     primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Name       string             `json:"name" bson:"name" validate:"required"`
+	Prefix     string             `json:"prefix" bson:"prefix"` // first characters of the key, to tell keys apart
+	KeyHash    string             `json:"-" bson:"key_hash"`    // SHA-256 of the full key
+	Scopes     []string           `json:"scopes" bson:"scopes"`
+	LastUsedAt *time.Time         `json:"last_used_at" bson:"last_used_at"`
+	ExpiresAt  *time.Time         `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	RevokedAt  *time.Time         `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	CreatedAt  time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// IsValidAPIKeyScope reports whether scope is a known API key scope
+func IsValidAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScope reports whether the key was granted the scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
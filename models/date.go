@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DateLayout is the format dates are accepted and returned in
+const DateLayout = "2006-01-02"
+
+// Date is a calendar day. It is stored in MongoDB as a date at midnight UTC
+// so it can be range-queried and sorted, and written as YYYY-MM-DD in JSON.
+type Date struct {
+	time.Time
+}
+
+// ParseDate parses a YYYY-MM-DD date
+func ParseDate(value string) (Date, error) {
+	t, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return Date{}, fmt.Errorf("%q is not a date in YYYY-MM-DD format", value)
+	}
+	return Date{t}, nil
+}
+
+// Today returns the current day in UTC
+func Today() Date {
+	now := time.Now().UTC()
+	return Date{time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var value *string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	if value == nil || *value == "" {
+		*d = Date{}
+		return nil
+	}
+
+	parsed, err := ParseDate(*value)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+func (d Date) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if d.IsZero() {
+		return bson.MarshalValue(nil)
+	}
+	return bson.MarshalValue(primitive.NewDateTimeFromTime(d.Time))
+}
+
+// UnmarshalBSONValue also reads the YYYY-MM-DD strings stored before dates
+// were typed, so documents stay readable until they are migrated
+func (d *Date) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	raw := bson.RawValue{Type: t, Value: data}
+	switch t {
+	case bsontype.DateTime:
+		*d = Date{raw.Time().UTC()}
+	case bsontype.String:
+		parsed, err := ParseDate(raw.StringValue())
+		if err != nil {
+			*d = Date{}
+			return nil
+		}
+		*d = parsed
+	case bsontype.Null, bsontype.Undefined:
+		*d = Date{}
+	default:
+		return fmt.Errorf("cannot decode %s into a date", t)
+	}
+	return nil
+}
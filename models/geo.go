@@ -0,0 +1,26 @@
+package models
+
+// GeoPoint is a GeoJSON point. Coordinates are stored as [longitude, latitude]
+// as MongoDB's 2dsphere index expects.
+type GeoPoint struct {
+	Type        string    `json:"type" bson:"type"`
+	Coordinates []float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// NewGeoPoint builds a GeoJSON point from a latitude and longitude
+func NewGeoPoint(lat, lng float64) *GeoPoint {
+	return &GeoPoint{
+		Type:        "Point",
+		Coordinates: []float64{lng, lat},
+	}
+}
+
+// Lat returns the latitude of the point
+func (p *GeoPoint) Lat() float64 {
+	return p.Coordinates[1]
+}
+
+// Lng returns the longitude of the point
+func (p *GeoPoint) Lng() float64 {
+	return p.Coordinates[0]
+}
@@ -20,14 +20,31 @@ type Property struct {
 	Bathrooms     int       `csv:"bathrooms" bson:"bathrooms"`
 	Amenities     []string  `csv:"amenities" bson:"amenities"`
 	Furnished     string    `csv:"furnished" bson:"furnished"`
-	AvailableFrom string    `csv:"availableFrom" bson:"availableFrom"`
+	AvailableFrom Date      `csv:"availableFrom" bson:"availableFrom"`
 	ListedBy      string    `csv:"listedBy" bson:"listedBy"`
 	Tags          []string  `csv:"tags" bson:"tags"`
 	ColorTheme    string    `csv:"colorTheme" bson:"colorTheme"`
 	Rating        float64   `csv:"rating" bson:"rating"`
 	IsVerified    bool      `csv:"isVerified" bson:"isVerified"`
 	ListingType   string    `csv:"listingType" bson:"listingType"`
+	Location      *GeoPoint `csv:"-" json:"location,omitempty" bson:"location,omitempty"`
 	CreatedBy     string    `json:"created_by" bson:"created_by"`
 	CreatedAt     time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at" bson:"updated_at"`
 }
+
+// PropertySearchResult is a property returned by full-text search together
+// with its relevance score
+type PropertySearchResult struct {
+	Property `bson:",inline"`
+
+	Score float64 `json:"score" bson:"score"`
+}
+
+// PropertyWithDistance is a property returned by a near-point query together
+// with its distance from that point
+type PropertyWithDistance struct {
+	Property `bson:",inline"`
+
+	DistanceKm float64 `json:"distance_km" bson:"distance_km"`
+}
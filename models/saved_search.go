@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SavedSearch is a named set of GET /api/properties filter parameters.
+// New or updated properties matching it raise a SearchAlert.
+type SavedSearch struct {
+	mgm.DefaultModel `bson:",inline"`
+
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Name        string             `json:"name" bson:"name" validate:"required"`
+	Params      map[string]string  `json:"params" bson:"params"`
+	NotifyEmail bool               `json:"notify_email" bson:"notify_email"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// SearchAlert tells a user that a property matched one of their saved
+// searches. There is at most one alert per saved search and property.
+type SearchAlert struct {
+	mgm.DefaultModel `bson:",inline"`
+
+	UserID          primitive.ObjectID `json:"user_id" bson:"user_id"`
+	SavedSearchID   primitive.ObjectID `json:"saved_search_id" bson:"saved_search_id"`
+	SavedSearchName string             `json:"saved_search_name" bson:"saved_search_name"`
+	PropertyID      string             `json:"property_id" bson:"property_id"`
+	PropertyTitle   string             `json:"property_title" bson:"property_title"`
+	Read            bool               `json:"read" bson:"read"`
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+}
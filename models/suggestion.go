@@ -0,0 +1,15 @@
+package models
+
+import "github.com/kamva/mgm/v3"
+
+// Suggestion is an entry of the search autocomplete dictionary: a distinct
+// value of a property field and how many properties have it. The dictionary
+// is rebuilt from the properties, never edited directly.
+type Suggestion struct {
+	mgm.IDField `json:"-" bson:",inline"`
+
+	Field      string `json:"field" bson:"field"`
+	Value      string `json:"value" bson:"value"`
+	Normalized string `json:"-" bson:"normalized"` // lower case value, matched against the prefix
+	Count      int    `json:"count" bson:"count"`
+}
@@ -7,6 +7,13 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// User roles. Accounts without a stored role are treated as owners.
+const (
+	RoleAdmin = "admin"
+	RoleAgent = "agent"
+	RoleOwner = "owner"
+)
+
 type User struct {
 	mgm.DefaultModel `bson:",inline"`
 
@@ -15,9 +22,29 @@ type User struct {
 	FirstName               string               `json:"first_name" bson:"first_name" validate:"required"`
 	LastName                string               `json:"last_name" bson:"last_name" validate:"required"`
 	Phone                   string               `json:"phone" bson:"phone"`
+	Role                    string               `json:"role" bson:"role"`
+	EmailVerified           bool                 `json:"email_verified" bson:"email_verified"`
+	EmailVerifiedAt         *time.Time           `json:"email_verified_at,omitempty" bson:"email_verified_at,omitempty"`
+	TwoFactorEnabled        bool                 `json:"two_factor_enabled" bson:"two_factor_enabled"`
+	TwoFactorSecret         string               `json:"-" bson:"two_factor_secret,omitempty"`
+	TwoFactorPendingSecret  string               `json:"-" bson:"two_factor_pending_secret,omitempty"`
+	RecoveryCodes           []string             `json:"-" bson:"recovery_codes,omitempty"` // SHA-256 hashes
 	CreatedAt               time.Time            `json:"created_at" bson:"created_at"`
 	UpdatedAt               time.Time            `json:"updated_at" bson:"updated_at"`
 	Favorites               []string             `json:"favorites" bson:"favorites"`
 	RecommendationsSent     []primitive.ObjectID `json:"recommendations_sent" bson:"recommendations_sent"`
 	RecommendationsReceived []primitive.ObjectID `json:"recommendations_received" bson:"recommendations_received"`
 }
+
+// GetRole returns the user's role, defaulting to owner for legacy accounts
+func (u *User) GetRole() string {
+	if u.Role == "" {
+		return RoleOwner
+	}
+	return u.Role
+}
+
+// IsValidRole reports whether role is one of the known user roles
+func IsValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleAgent || role == RoleOwner
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"property_lister/controllers"
+	"property_lister/middleware"
+	"property_lister/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupAdminRoutes(app *fiber.App) {
+	api := app.Group("/api")
+
+	admin := api.Group("/admin", middleware.AuthMiddleware(), middleware.RequireRole(models.RoleAdmin))
+
+	admin.Patch("/users/:id/role", controllers.UpdateUserRole)
+	admin.Delete("/users/:id", controllers.DeleteUser)
+	admin.Post("/users/:id/unlock", controllers.UnlockUser)
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"property_lister/controllers"
+	"property_lister/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupAPIKeyRoutes(app *fiber.App) {
+	api := app.Group("/api")
+
+	// Managing keys requires a user session, an API key can't mint new keys
+	apiKeys := api.Group("/api-keys", middleware.AuthMiddleware())
+
+	apiKeys.Get("/", controllers.GetAPIKeys)
+	apiKeys.Post("/", controllers.CreateAPIKey)
+	apiKeys.Delete("/:id", controllers.RevokeAPIKey)
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"property_lister/controllers"
 	"property_lister/middleware"
+	"property_lister/models"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -10,14 +11,14 @@ import (
 func SetupFavoriteRoutes(app *fiber.App) {
 	api := app.Group("/api")
 
-	favorites := api.Group("/favorites", middleware.AuthMiddleware())
+	favorites := api.Group("/favorites", middleware.AuthOrAPIKey())
 
 	// Get user's favorites
-	favorites.Get("/", controllers.GetFavorites)
+	favorites.Get("/", middleware.RequireScope(models.ScopeFavoritesRead), controllers.GetFavorites)
 
 	// Add to favorites
-	favorites.Post("/:propertyId", controllers.AddToFavorites)
+	favorites.Post("/:propertyId", middleware.RequireScope(models.ScopeFavoritesWrite), controllers.AddToFavorites)
 
 	// Remove from favorites
-	favorites.Delete("/:propertyId", controllers.RemoveFromFavorites)
+	favorites.Delete("/:propertyId", middleware.RequireScope(models.ScopeFavoritesWrite), controllers.RemoveFromFavorites)
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"property_lister/controllers"
+	"property_lister/middleware"
+	"property_lister/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupFeedRoutes(app *fiber.App) {
+	api := app.Group("/api")
+
+	// Personalized feed, built from favorites and accepted recommendations
+	api.Get("/feed", middleware.AuthOrAPIKey(), middleware.RequireScope(models.ScopeFavoritesRead), controllers.GetFeed)
+}
@@ -3,6 +3,7 @@ package routes
 import (
 	"property_lister/controllers"
 	"property_lister/middleware"
+	"property_lister/models"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -10,10 +11,10 @@ import (
 func SetupListingRoutes(app *fiber.App) {
 	api := app.Group("/api")
 
-	listings := api.Group("/listings", middleware.AuthMiddleware())
+	listings := api.Group("/listings", middleware.AuthOrAPIKey())
 
-	listings.Get("/", controllers.GetListings)
-	listings.Put("/", controllers.CreateListing)
-	listings.Patch("/:id", controllers.UpdateListing)
-	listings.Delete("/:id", controllers.DeleteListing)
+	listings.Get("/", middleware.RequireScope(models.ScopeListingsRead), controllers.GetListings)
+	listings.Put("/", middleware.RequireScope(models.ScopeListingsWrite), middleware.RequireVerifiedEmail(), controllers.CreateListing)
+	listings.Patch("/:id", middleware.RequireScope(models.ScopeListingsWrite), controllers.UpdateListing)
+	listings.Delete("/:id", middleware.RequireScope(models.ScopeListingsWrite), controllers.DeleteListing)
 }
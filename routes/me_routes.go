@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"property_lister/controllers"
+	"property_lister/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupMeRoutes(app *fiber.App) {
+	api := app.Group("/api")
+
+	me := api.Group("/me", middleware.AuthMiddleware())
+
+	me.Get("/", controllers.GetMe)
+	me.Patch("/", controllers.UpdateMe)
+	me.Delete("/", controllers.DeleteMe)
+	me.Post("/password", controllers.ChangePassword)
+	me.Get("/export", controllers.ExportMe)
+
+	me.Post("/2fa/setup", controllers.SetupTwoFactor)
+	me.Post("/2fa/verify", controllers.VerifyTwoFactor)
+	me.Post("/2fa/disable", controllers.DisableTwoFactor)
+}
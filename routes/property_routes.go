@@ -2,6 +2,8 @@ package routes
 
 import (
 	"property_lister/controllers"
+	"property_lister/middleware"
+	"property_lister/models"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -9,9 +11,15 @@ import (
 func SetupPropertyRoutes(app *fiber.App) {
 	api := app.Group("/api")
 
-	properties := api.Group("/properties")
+	// Public, but API keys presented here need the properties:read scope
+	properties := api.Group("/properties", middleware.OptionalAPIKey(), middleware.RequireScope(models.ScopePropertiesRead))
 
 	properties.Get("/", controllers.GetProperties)
 	properties.Get("/search", controllers.SearchProperties)
+	properties.Get("/suggest", controllers.SuggestProperties)
+	properties.Get("/export", controllers.ExportProperties)
+	properties.Get("/compare", controllers.CompareProperties)
+	properties.Post("/batch", controllers.BatchGetProperties)
 	properties.Get("/:id", controllers.GetPropertyByID)
+	properties.Get("/:id/similar", controllers.GetSimilarProperties)
 }
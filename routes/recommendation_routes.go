@@ -3,16 +3,18 @@ package routes
 import (
 	"property_lister/controllers"
 	"property_lister/middleware"
+	"property_lister/models"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func SetupRecommendationRoutes(app *fiber.App) {
 	api := app.Group("/api")
-	recommendations := api.Group("/recommendations", middleware.AuthMiddleware())
+	recommendations := api.Group("/recommendations", middleware.AuthOrAPIKey())
 
-	recommendations.Post("/send", controllers.SendRecommendation)
-	recommendations.Get("/", controllers.GetUserRecommendations)
-	recommendations.Get("/sent", controllers.GetSentRecommendations)
-	recommendations.Get("/received", controllers.GetReceivedRecommendations)
+	recommendations.Post("/send", middleware.RequireScope(models.ScopeRecommendationsWrite), middleware.RequireVerifiedEmail(), controllers.SendRecommendation)
+	recommendations.Get("/", middleware.RequireScope(models.ScopeRecommendationsRead), controllers.GetUserRecommendations)
+	recommendations.Get("/sent", middleware.RequireScope(models.ScopeRecommendationsRead), controllers.GetSentRecommendations)
+	recommendations.Get("/received", middleware.RequireScope(models.ScopeRecommendationsRead), controllers.GetReceivedRecommendations)
+	recommendations.Patch("/:id", middleware.RequireScope(models.ScopeRecommendationsWrite), controllers.UpdateRecommendationStatus)
 }
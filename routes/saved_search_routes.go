@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"property_lister/controllers"
+	"property_lister/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupSavedSearchRoutes(app *fiber.App) {
+	api := app.Group("/api")
+
+	savedSearches := api.Group("/saved-searches", middleware.AuthMiddleware())
+
+	savedSearches.Get("/", controllers.GetSavedSearches)
+	savedSearches.Post("/", controllers.CreateSavedSearch)
+	savedSearches.Delete("/:id", controllers.DeleteSavedSearch)
+
+	// Alerts raised by new or updated properties matching a saved search
+	savedSearches.Get("/alerts", controllers.GetSearchAlerts)
+	savedSearches.Post("/alerts/read", controllers.MarkSearchAlertsRead)
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"property_lister/controllers"
+	"property_lister/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -13,4 +14,12 @@ func SetupUserRoutes(app *fiber.App) {
 
 	auth.Post("/register", controllers.RegisterUser)
 	auth.Post("/login", controllers.LoginUser)
+	auth.Post("/login/2fa", controllers.LoginTwoFactor)
+	auth.Post("/refresh", controllers.RefreshToken)
+	auth.Post("/logout", middleware.AuthMiddleware(), controllers.LogoutUser)
+	auth.Post("/logout-all", middleware.AuthMiddleware(), controllers.LogoutAllSessions)
+	auth.Post("/forgot-password", controllers.ForgotPassword)
+	auth.Post("/reset-password", controllers.ResetPassword)
+	auth.Get("/verify", controllers.VerifyEmail)
+	auth.Post("/resend-verification", middleware.AuthMiddleware(), controllers.ResendVerificationEmail)
 }
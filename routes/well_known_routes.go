@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"property_lister/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func SetupWellKnownRoutes(app *fiber.App) {
+	wellKnown := app.Group("/.well-known")
+
+	wellKnown.Get("/jwks.json", controllers.GetJWKS)
+}
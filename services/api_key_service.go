@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	APIKeyPrefix = "plk_"
+	// How often the last used timestamp of a key is written back at most
+	apiKeyTouchInterval = time.Minute
+)
+
+var ErrInvalidAPIKey = errors.New("invalid, expired or revoked API key")
+
+// GenerateAPIKey returns a new random API key together with its display
+// prefix and the hash that gets stored
+func GenerateAPIKey() (key, prefix, keyHash string, err error) {
+	secret, err := generateRandomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key = APIKeyPrefix + secret
+	return key, key[:len(APIKeyPrefix)+8], hashToken(key), nil
+}
+
+// AuthenticateAPIKey looks up an active API key and the user owning it
+func AuthenticateAPIKey(key string) (*models.APIKey, *models.User, error) {
+	var apiKey models.APIKey
+	err := mgm.Coll(&apiKey).FindOne(mgm.Ctx(), bson.M{
+		"key_hash":   hashToken(key),
+		"revoked_at": bson.M{"$exists": false},
+	}).Decode(&apiKey)
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(now) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	var user models.User
+	err = mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": apiKey.UserID}).Decode(&user)
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > apiKeyTouchInterval {
+		go touchAPIKey(&apiKey, now)
+	}
+
+	return &apiKey, &user, nil
+}
+
+func touchAPIKey(apiKey *models.APIKey, usedAt time.Time) {
+	_, err := mgm.Coll(apiKey).UpdateOne(mgm.Ctx(), bson.M{"_id": apiKey.ID}, bson.M{
+		"$set": bson.M{"last_used_at": usedAt},
+	})
+	if err != nil {
+		log.Printf("Failed to update last used time of API key %s: %v", apiKey.ID.Hex(), err)
+	}
+}
@@ -17,6 +17,10 @@ import (
 
 const CacheExpiry = 10 * time.Minute
 
+// UserListingsSort is the order of a user's listings, newest first. _id breaks
+// ties so the cached list and cursor pages agree on the order.
+var UserListingsSort = bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: 1}}
+
 func GetCacheKey(prefix, userID, suffix string) string {
 	if suffix != "" {
 		return fmt.Sprintf("%s:%s:%s", prefix, userID, suffix)
@@ -76,6 +80,11 @@ func DeleteCachePattern(pattern string) error {
 
 // InvalidateUserCache removes all cached data for a user
 func InvalidateUserCache(userID string) error {
+	// Keys without a suffix don't match the pattern below
+	for _, prefix := range []string{"user_favorites", "user_favorite_properties", "user_listings", "user_profile", "user_feed"} {
+		DeleteCache(GetCacheKey(prefix, userID, ""))
+	}
+
 	pattern := fmt.Sprintf("*:%s:*", userID)
 	return DeleteCachePattern(pattern)
 }
@@ -149,7 +158,7 @@ func CacheUserListings(userID string) {
 	var listings []models.Property
 	cursor, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), bson.M{
 		"created_by": userID,
-	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	}, options.Find().SetSort(UserListingsSort))
 	if err != nil {
 		return
 	}
@@ -259,7 +268,7 @@ func UpdateListingsCache(userID string) {
 	var listings []models.Property
 	cursor, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), bson.M{
 		"created_by": userID,
-	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	}, options.Find().SetSort(UserListingsSort))
 	if err != nil {
 		return
 	}
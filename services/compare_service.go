@@ -0,0 +1,214 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"property_lister/models"
+)
+
+const (
+	MinCompareProperties = 2
+	MaxCompareProperties = 5
+)
+
+// ComparedProperty is a property reduced to the attributes being compared,
+// with set attributes normalized to sorted lowercase values
+type ComparedProperty struct {
+	ID            string      `json:"id"`
+	Title         string      `json:"title"`
+	Type          string      `json:"type"`
+	City          string      `json:"city"`
+	State         string      `json:"state"`
+	ListingType   string      `json:"listing_type"`
+	Furnished     string      `json:"furnished"`
+	Price         int         `json:"price"`
+	AreaSqFt      int         `json:"area_sqft"`
+	PricePerSqFt  float64     `json:"price_per_sqft"`
+	Bedrooms      int         `json:"bedrooms"`
+	Bathrooms     int         `json:"bathrooms"`
+	Rating        float64     `json:"rating"`
+	IsVerified    bool        `json:"is_verified"`
+	AvailableFrom models.Date `json:"available_from"`
+	Amenities     []string    `json:"amenities"`
+	Tags          []string    `json:"tags"`
+}
+
+// SetComparison splits a set attribute into the values all properties
+// share and the values only some of them have
+type SetComparison struct {
+	Common []string            `json:"common"`
+	Unique map[string][]string `json:"unique"` // values only this property has
+	Some   []string            `json:"some"`   // values some but not all properties have
+}
+
+// Highlight names the best and worst properties for one attribute.
+// Ties list every tied property.
+type Highlight struct {
+	Best  []string `json:"best"`
+	Worst []string `json:"worst"`
+}
+
+// PropertyComparison is the side-by-side comparison of a few properties
+type PropertyComparison struct {
+	Properties []ComparedProperty    `json:"properties"`
+	Amenities  SetComparison         `json:"amenities"`
+	Tags       SetComparison         `json:"tags"`
+	Highlights map[string]*Highlight `json:"highlights"`
+}
+
+// CompareProperties builds the comparison of the properties in the given order
+func CompareProperties(properties []models.Property) *PropertyComparison {
+	comparison := &PropertyComparison{Highlights: map[string]*Highlight{}}
+	for _, p := range properties {
+		compared := ComparedProperty{
+			ID:            p.ID,
+			Title:         p.Title,
+			Type:          p.Type,
+			City:          p.City,
+			State:         p.State,
+			ListingType:   strings.ToLower(p.ListingType),
+			Furnished:     strings.ToLower(p.Furnished),
+			Price:         p.Price,
+			AreaSqFt:      p.AreaSqFt,
+			Bedrooms:      p.Bedrooms,
+			Bathrooms:     p.Bathrooms,
+			Rating:        p.Rating,
+			IsVerified:    p.IsVerified,
+			AvailableFrom: p.AvailableFrom,
+			Amenities:     normalizeSet(p.Amenities),
+			Tags:          normalizeSet(p.Tags),
+		}
+		if p.AreaSqFt > 0 {
+			compared.PricePerSqFt = math.Round(float64(p.Price)/float64(p.AreaSqFt)*100) / 100
+		}
+		comparison.Properties = append(comparison.Properties, compared)
+	}
+
+	props := comparison.Properties
+	comparison.Amenities = compareSets(props, func(p ComparedProperty) []string { return p.Amenities })
+	comparison.Tags = compareSets(props, func(p ComparedProperty) []string { return p.Tags })
+
+	// Lower is better for prices, higher for everything else
+	attributes := []struct {
+		name          string
+		value         func(p ComparedProperty) float64
+		lowerIsBetter bool
+	}{
+		{"price", func(p ComparedProperty) float64 { return float64(p.Price) }, true},
+		{"price_per_sqft", func(p ComparedProperty) float64 { return p.PricePerSqFt }, true},
+		{"area_sqft", func(p ComparedProperty) float64 { return float64(p.AreaSqFt) }, false},
+		{"bedrooms", func(p ComparedProperty) float64 { return float64(p.Bedrooms) }, false},
+		{"bathrooms", func(p ComparedProperty) float64 { return float64(p.Bathrooms) }, false},
+		{"rating", func(p ComparedProperty) float64 { return p.Rating }, false},
+		{"amenities", func(p ComparedProperty) float64 { return float64(len(p.Amenities)) }, false},
+		// Available sooner is better
+		{"available_from", func(p ComparedProperty) float64 { return float64(p.AvailableFrom.Unix()) }, true},
+	}
+	for _, attr := range attributes {
+		if attr.name == "price_per_sqft" && anyZeroArea(props) {
+			continue
+		}
+		if attr.name == "available_from" && anyUnknownDate(props) {
+			continue
+		}
+		comparison.Highlights[attr.name] = highlight(props, attr.value, attr.lowerIsBetter)
+	}
+
+	return comparison
+}
+
+func normalizeSet(values []string) []string {
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		normalized = append(normalized, value)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
+
+func compareSets(props []ComparedProperty, values func(p ComparedProperty) []string) SetComparison {
+	counts := map[string]int{}
+	for _, p := range props {
+		for _, value := range values(p) {
+			counts[value]++
+		}
+	}
+
+	result := SetComparison{Common: []string{}, Unique: map[string][]string{}, Some: []string{}}
+	for value, count := range counts {
+		switch {
+		case count == len(props):
+			result.Common = append(result.Common, value)
+		case count < len(props):
+			result.Some = append(result.Some, value)
+		}
+	}
+	sort.Strings(result.Common)
+	sort.Strings(result.Some)
+
+	for _, p := range props {
+		unique := []string{}
+		for _, value := range values(p) {
+			if counts[value] == 1 {
+				unique = append(unique, value)
+			}
+		}
+		result.Unique[p.ID] = unique
+	}
+
+	return result
+}
+
+func highlight(props []ComparedProperty, value func(p ComparedProperty) float64, lowerIsBetter bool) *Highlight {
+	min, max := value(props[0]), value(props[0])
+	for _, p := range props[1:] {
+		min = math.Min(min, value(p))
+		max = math.Max(max, value(p))
+	}
+
+	best, worst := max, min
+	if lowerIsBetter {
+		best, worst = min, max
+	}
+
+	h := &Highlight{Best: []string{}, Worst: []string{}}
+	if min == max {
+		// Nothing stands out when all are equal
+		return h
+	}
+	for _, p := range props {
+		if value(p) == best {
+			h.Best = append(h.Best, p.ID)
+		}
+		if value(p) == worst {
+			h.Worst = append(h.Worst, p.ID)
+		}
+	}
+	return h
+}
+
+func anyZeroArea(props []ComparedProperty) bool {
+	for _, p := range props {
+		if p.AreaSqFt <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func anyUnknownDate(props []ComparedProperty) bool {
+	for _, p := range props {
+		if p.AvailableFrom.IsZero() {
+			return true
+		}
+	}
+	return false
+}
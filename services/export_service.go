@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// How long a single export may run
+const exportTimeout = 10 * time.Minute
+
+// Export formats
+const (
+	ExportCSV     = "csv"
+	ExportNDJSON  = "ndjson"
+	ExportGeoJSON = "geojson"
+)
+
+var ErrUnknownExportFormat = errors.New("format must be one of csv, ndjson or geojson")
+
+// PropertyCSVHeader is the header of data/properties.csv. Exported CSV files
+// use it too, so they can be ingested again.
+var PropertyCSVHeader = []string{
+	"id", "title", "type", "price", "state", "city", "areaSqFt", "bedrooms",
+	"bathrooms", "amenities", "furnished", "availableFrom", "listedBy", "tags",
+	"colorTheme", "rating", "isVerified", "listingType",
+}
+
+// ExportContentTypes maps the export formats to their content type
+var ExportContentTypes = map[string]string{
+	ExportCSV:     "text/csv; charset=utf-8",
+	ExportNDJSON:  "application/x-ndjson",
+	ExportGeoJSON: "application/geo+json",
+}
+
+// PropertyEncoder writes properties in an export format, one at a time
+type PropertyEncoder interface {
+	Begin() error
+	Encode(property *models.Property) error
+	End() error
+}
+
+// NewPropertyEncoder returns the encoder of format writing to w
+func NewPropertyEncoder(format string, w io.Writer) (PropertyEncoder, error) {
+	switch format {
+	case ExportCSV:
+		return &csvPropertyEncoder{w: csv.NewWriter(w)}, nil
+	case ExportNDJSON:
+		return &ndjsonPropertyEncoder{enc: json.NewEncoder(w)}, nil
+	case ExportGeoJSON:
+		return &geoJSONPropertyEncoder{w: w}, nil
+	}
+	return nil, ErrUnknownExportFormat
+}
+
+// PropertyExport is an open export: a cursor over the matching properties
+// that is encoded when written
+type PropertyExport struct {
+	cursor *mongo.Cursor
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// OpenPropertyExport runs the query of an export. Properties are read from
+// the cursor as they are written, so large exports are never held in memory.
+func OpenPropertyExport(filter bson.M) (*PropertyExport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+
+	// Ordered by ID so exports of the same data come out the same
+	findOptions := options.Find().SetSort(bson.D{{Key: "id", Value: 1}}).SetAllowDiskUse(true)
+	cursor, err := mgm.Coll(&models.Property{}).Find(ctx, filter, findOptions)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &PropertyExport{cursor: cursor, ctx: ctx, cancel: cancel}, nil
+}
+
+// WriteTo encodes every property of the export and closes it
+func (e *PropertyExport) WriteTo(encoder PropertyEncoder) error {
+	defer e.cancel()
+	defer e.cursor.Close(e.ctx)
+
+	if err := encoder.Begin(); err != nil {
+		return err
+	}
+	for e.cursor.Next(e.ctx) {
+		var property models.Property
+		if err := e.cursor.Decode(&property); err != nil {
+			return err
+		}
+		if err := encoder.Encode(&property); err != nil {
+			return err
+		}
+	}
+	if err := e.cursor.Err(); err != nil {
+		return err
+	}
+	return encoder.End()
+}
+
+type csvPropertyEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvPropertyEncoder) Begin() error {
+	return e.w.Write(PropertyCSVHeader)
+}
+
+func (e *csvPropertyEncoder) Encode(p *models.Property) error {
+	// Booleans are written the way the ingestion reads them
+	isVerified := "False"
+	if p.IsVerified {
+		isVerified = "True"
+	}
+
+	return e.w.Write([]string{
+		p.ID,
+		p.Title,
+		p.Type,
+		strconv.Itoa(p.Price),
+		p.State,
+		p.City,
+		strconv.Itoa(p.AreaSqFt),
+		strconv.Itoa(p.Bedrooms),
+		strconv.Itoa(p.Bathrooms),
+		strings.Join(p.Amenities, "|"),
+		p.Furnished,
+		p.AvailableFrom.String(),
+		p.ListedBy,
+		strings.Join(p.Tags, "|"),
+		p.ColorTheme,
+		formatRating(p.Rating),
+		isVerified,
+		p.ListingType,
+	})
+}
+
+func (e *csvPropertyEncoder) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// formatRating writes ratings with at least one decimal, like "2.0" in the
+// source data
+func formatRating(rating float64) string {
+	formatted := strconv.FormatFloat(rating, 'f', -1, 64)
+	if !strings.Contains(formatted, ".") {
+		formatted += ".0"
+	}
+	return formatted
+}
+
+type ndjsonPropertyEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonPropertyEncoder) Begin() error {
+	return nil
+}
+
+// Encode writes the property as in API responses, on a line of its own
+func (e *ndjsonPropertyEncoder) Encode(p *models.Property) error {
+	return e.enc.Encode(p)
+}
+
+func (e *ndjsonPropertyEncoder) End() error {
+	return nil
+}
+
+// geoJSONPropertyEncoder writes a FeatureCollection with a Point feature per
+// property. Properties without a location get a null geometry.
+type geoJSONPropertyEncoder struct {
+	w     io.Writer
+	count int
+}
+
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	ID         string           `json:"id"`
+	Geometry   *models.GeoPoint `json:"geometry"`
+	Properties *models.Property `json:"properties"`
+}
+
+func (e *geoJSONPropertyEncoder) Begin() error {
+	_, err := io.WriteString(e.w, `{"type":"FeatureCollection","features":[`)
+	return err
+}
+
+func (e *geoJSONPropertyEncoder) Encode(p *models.Property) error {
+	geometry := p.Location
+	properties := *p
+	properties.Location = nil
+
+	data, err := json.Marshal(geoJSONFeature{
+		Type:       "Feature",
+		ID:         p.ID,
+		Geometry:   geometry,
+		Properties: &properties,
+	})
+	if err != nil {
+		return err
+	}
+
+	if e.count > 0 {
+		if _, err := io.WriteString(e.w, ",\n"); err != nil {
+			return err
+		}
+	} else if _, err := io.WriteString(e.w, "\n"); err != nil {
+		return err
+	}
+	e.count++
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *geoJSONPropertyEncoder) End() error {
+	_, err := io.WriteString(e.w, "\n]}\n")
+	return err
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	PriceFacet          = "price"
+	DefaultPriceBuckets = 8
+	MaxPriceBuckets     = 50
+	// Facets with many distinct values only report the most common ones
+	maxFacetValues = 50
+)
+
+// propertyFacetFields maps facet names to the property field they count
+var propertyFacetFields = map[string]string{
+	"type":         "type",
+	"furnished":    "furnished",
+	"listing_type": "listingType",
+	"city":         "city",
+	"bedrooms":     "bedrooms",
+	"amenities":    "amenities",
+	"tags":         "tags",
+}
+
+// PropertyFacetNames lists every facet that can be requested, in response order
+var PropertyFacetNames = []string{"type", "furnished", "listing_type", "city", "bedrooms", "amenities", "tags", PriceFacet}
+
+// FacetBucket is the number of matching properties with one value of a field
+type FacetBucket struct {
+	Value interface{} `json:"value" bson:"_id"`
+	Count int64       `json:"count" bson:"count"`
+}
+
+// PriceBucket is one bar of the price histogram. Buckets cover min up to but
+// excluding max, except for the last one which includes max.
+type PriceBucket struct {
+	Min   int   `json:"min" bson:"min"`
+	Max   int   `json:"max" bson:"max"`
+	Count int64 `json:"count" bson:"count"`
+}
+
+// ParseFacets parses a comma separated facet list, "all" selects every facet
+func ParseFacets(param string) ([]string, error) {
+	if strings.TrimSpace(param) == "all" {
+		return PropertyFacetNames, nil
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.Split(param, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if _, ok := propertyFacetFields[name]; !ok && name != PriceFacet {
+			return nil, fmt.Errorf("unknown facet %q", name)
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no facets requested")
+	}
+	return names, nil
+}
+
+// GetPropertyFacets counts the properties matching filter per value of each
+// requested facet in a single aggregation
+func GetPropertyFacets(filter bson.M, names []string, priceBuckets int) (map[string]interface{}, error) {
+	stages := bson.M{}
+	for _, name := range names {
+		if name == PriceFacet {
+			stages[name] = bson.A{
+				bson.M{"$bucketAuto": bson.M{"groupBy": "$price", "buckets": priceBuckets}},
+				bson.M{"$project": bson.M{"_id": 0, "min": "$_id.min", "max": "$_id.max", "count": 1}},
+			}
+			continue
+		}
+
+		field := propertyFacetFields[name]
+		stage := bson.A{}
+		if field == "amenities" || field == "tags" {
+			stage = append(stage, bson.M{"$unwind": "$" + field})
+		}
+
+		// Bedroom counts read best in numeric order, everything else by popularity
+		sort := bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}
+		if field == "bedrooms" {
+			sort = bson.D{{Key: "_id", Value: 1}}
+		}
+
+		stages[name] = append(stage,
+			bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": sort},
+			bson.M{"$limit": maxFacetValues},
+		)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$facet", Value: stages}},
+	}
+
+	cursor, err := mgm.Coll(&models.Property{}).Aggregate(mgm.Ctx(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(mgm.Ctx())
+
+	var results []map[string]bson.RawValue
+	if err = cursor.All(mgm.Ctx(), &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, fmt.Errorf("facet aggregation returned no result")
+	}
+
+	facets := map[string]interface{}{}
+	for _, name := range names {
+		raw := results[0][name]
+		if name == PriceFacet {
+			buckets := []PriceBucket{}
+			if err := raw.Unmarshal(&buckets); err != nil {
+				return nil, err
+			}
+			facets[name] = buckets
+			continue
+		}
+
+		buckets := []FacetBucket{}
+		if err := raw.Unmarshal(&buckets); err != nil {
+			return nil, err
+		}
+		facets[name] = buckets
+	}
+
+	return facets, nil
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFacets(t *testing.T) {
+	tests := []struct {
+		param   string
+		want    []string
+		wantErr bool
+	}{
+		{"type", []string{"type"}, false},
+		{"city, price ,type", []string{"city", "price", "type"}, false},
+		{"city,city,,price", []string{"city", "price"}, false},
+		{"all", PropertyFacetNames, false},
+		{" all ", PropertyFacetNames, false},
+		{"", nil, true},
+		{" , ", nil, true},
+		{"city,colour", nil, true},
+		{"Type", nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseFacets(tt.param)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFacets(%q) error = %v, want error %v", tt.param, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseFacets(%q) = %v, want %v", tt.param, got, tt.want)
+		}
+	}
+}
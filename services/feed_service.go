@@ -0,0 +1,394 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	DefaultFeedLimit = 20
+	MaxFeedLimit     = 50
+)
+
+// Weights of the feed score components, they add up to 1
+const (
+	feedCityWeight      = 0.30
+	feedTypeWeight      = 0.25
+	feedPriceWeight     = 0.20
+	feedAmenitiesWeight = 0.15
+	feedRatingWeight    = 0.10
+)
+
+// Number of preferred amenities kept in a profile
+const feedTopAmenities = 10
+
+// PreferenceProfile summarises what a user likes, built from their favorites
+// and the recommendations they accepted
+type PreferenceProfile struct {
+	Cities       map[string]int `json:"cities"`
+	Types        map[string]int `json:"types"`
+	Amenities    []string       `json:"amenities"`
+	ListingTypes []string       `json:"listing_types"`
+	MinPrice     int            `json:"min_price"`
+	MaxPrice     int            `json:"max_price"`
+	Signals      int            `json:"signals"`
+}
+
+// FeedItem is a property in a user's feed with its score and the reasons it
+// was picked
+type FeedItem struct {
+	models.Property
+
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// Feed is the ranked list of properties for a user. Personalized is false
+// when the user has no signals yet and the feed holds popular listings.
+type Feed struct {
+	Items        []FeedItem         `json:"items"`
+	Profile      *PreferenceProfile `json:"profile,omitempty"`
+	Personalized bool               `json:"personalized"`
+}
+
+// GetUserFeed returns the feed of a user, from the cache when possible
+func GetUserFeed(userID string) (*Feed, error) {
+	cacheKey := GetCacheKey("user_feed", userID, "")
+
+	var feed Feed
+	if err := GetCache(cacheKey, &feed); err == nil {
+		return &feed, nil
+	}
+
+	built, err := BuildUserFeed(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	go SetCache(cacheKey, built)
+	return built, nil
+}
+
+// InvalidateUserFeed drops the cached feed of a user, it is rebuilt on the
+// next request
+func InvalidateUserFeed(userID string) {
+	if err := DeleteCache(GetCacheKey("user_feed", userID, "")); err != nil {
+		log.Printf("Failed to invalidate feed of user %s: %v", userID, err)
+	}
+}
+
+// BuildUserFeed ranks the properties the user has not seen yet against their
+// preference profile, keeping the best MaxFeedLimit. Candidates are picked by
+// city, type, price and rating before being scored.
+func BuildUserFeed(userID string) (*Feed, error) {
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": objID}).Decode(&user); err != nil {
+		return nil, err
+	}
+
+	signals, err := userSignalProperties(&user)
+	if err != nil {
+		return nil, err
+	}
+
+	// Properties the user already knows about are left out of the feed
+	seen := append([]string{}, user.Favorites...)
+	for _, property := range signals {
+		seen = append(seen, property.ID)
+	}
+	received, err := receivedRecommendationPropertyIDs(user.Email)
+	if err != nil {
+		return nil, err
+	}
+	seen = append(seen, received...)
+
+	if len(signals) == 0 {
+		items, err := popularFeed(seen)
+		if err != nil {
+			return nil, err
+		}
+		return &Feed{Items: items, Personalized: false}, nil
+	}
+
+	profile := BuildPreferenceProfile(signals)
+
+	filter := bson.M{"id": bson.M{"$nin": seen}}
+	if len(profile.ListingTypes) > 0 {
+		filter["listingType"] = bson.M{"$in": profile.ListingTypes}
+	}
+	filter["$or"] = bson.A{
+		bson.M{"city": bson.M{"$in": mapKeys(profile.Cities)}},
+		bson.M{"type": bson.M{"$in": mapKeys(profile.Types)}},
+	}
+
+	prescore := bson.A{
+		weightIf(bson.M{"$in": bson.A{"$city", mapKeys(profile.Cities)}}, feedCityWeight),
+		weightIf(bson.M{"$in": bson.A{"$type", mapKeys(profile.Types)}}, feedTypeWeight),
+		bson.M{"$multiply": bson.A{feedRatingWeight / 5, bson.M{"$min": bson.A{bson.M{"$ifNull": bson.A{"$rating", 0}}, 5}}}},
+	}
+	if profile.MaxPrice > 0 {
+		prescore = append(prescore, weightIf(bson.M{"$and": bson.A{
+			bson.M{"$gte": bson.A{"$price", profile.MinPrice}},
+			bson.M{"$lte": bson.A{"$price", profile.MaxPrice}},
+		}}, feedPriceWeight))
+	}
+	candidates, err := topCandidates(filter, prescore)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]FeedItem, 0, len(candidates))
+	for _, candidate := range candidates {
+		score, reasons := profile.Score(&candidate)
+		items = append(items, FeedItem{Property: candidate, Score: score, Reasons: reasons})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].ID < items[j].ID
+	})
+	if len(items) > MaxFeedLimit {
+		items = items[:MaxFeedLimit]
+	}
+
+	return &Feed{Items: items, Profile: profile, Personalized: true}, nil
+}
+
+// BuildPreferenceProfile derives a preference profile from the properties a
+// user showed interest in
+func BuildPreferenceProfile(properties []models.Property) *PreferenceProfile {
+	profile := &PreferenceProfile{
+		Cities:       map[string]int{},
+		Types:        map[string]int{},
+		Amenities:    []string{},
+		ListingTypes: []string{},
+		Signals:      len(properties),
+	}
+
+	amenityCounts := map[string]int{}
+	listingTypes := map[string]bool{}
+	minPrice, maxPrice := math.MaxInt, 0
+	for _, property := range properties {
+		profile.Cities[property.City]++
+		profile.Types[property.Type]++
+		for _, amenity := range normalizeSet(property.Amenities) {
+			amenityCounts[amenity]++
+		}
+		if property.ListingType != "" && !listingTypes[property.ListingType] {
+			listingTypes[property.ListingType] = true
+			profile.ListingTypes = append(profile.ListingTypes, property.ListingType)
+		}
+		if property.Price > 0 {
+			minPrice = min(minPrice, property.Price)
+			maxPrice = max(maxPrice, property.Price)
+		}
+	}
+
+	// Widen the price range a little so close matches are not left out
+	if maxPrice > 0 {
+		profile.MinPrice = int(float64(minPrice) * 0.8)
+		profile.MaxPrice = int(float64(maxPrice) * 1.2)
+	}
+
+	for amenity := range amenityCounts {
+		profile.Amenities = append(profile.Amenities, amenity)
+	}
+	sort.Slice(profile.Amenities, func(i, j int) bool {
+		a, b := profile.Amenities[i], profile.Amenities[j]
+		if amenityCounts[a] != amenityCounts[b] {
+			return amenityCounts[a] > amenityCounts[b]
+		}
+		return a < b
+	})
+	if len(profile.Amenities) > feedTopAmenities {
+		profile.Amenities = profile.Amenities[:feedTopAmenities]
+	}
+	sort.Strings(profile.ListingTypes)
+
+	return profile
+}
+
+// Score rates how well property fits the profile, from 0 to 1. City and type
+// count in proportion to how often they appear in the user's signals.
+func (p *PreferenceProfile) Score(property *models.Property) (float64, []string) {
+	score := 0.0
+	reasons := []string{}
+
+	if count := p.Cities[property.City]; count > 0 {
+		score += feedCityWeight * float64(count) / float64(p.Signals)
+		reasons = append(reasons, fmt.Sprintf("In a city you like (%s)", property.City))
+	}
+
+	if count := p.Types[property.Type]; count > 0 {
+		score += feedTypeWeight * float64(count) / float64(p.Signals)
+		reasons = append(reasons, fmt.Sprintf("A type you like (%s)", property.Type))
+	}
+
+	if p.MaxPrice > 0 {
+		if property.Price >= p.MinPrice && property.Price <= p.MaxPrice {
+			score += feedPriceWeight
+			reasons = append(reasons, "In your price range")
+		} else if property.Price < p.MinPrice {
+			score += feedPriceWeight * ratioCloseness(float64(property.Price), float64(p.MinPrice))
+		} else {
+			score += feedPriceWeight * ratioCloseness(float64(property.Price), float64(p.MaxPrice))
+		}
+	}
+
+	if len(p.Amenities) > 0 {
+		_, shared := Jaccard(p.Amenities, property.Amenities)
+		if len(shared) > 0 {
+			score += feedAmenitiesWeight * float64(len(shared)) / float64(len(p.Amenities))
+			reasons = append(reasons, "Has amenities you like: "+strings.Join(shared, ", "))
+		}
+	}
+
+	score += feedRatingWeight * math.Min(property.Rating, 5) / 5
+
+	return math.Round(score*1000) / 1000, reasons
+}
+
+// userSignalProperties loads the user's favorite properties and the ones from
+// recommendations they accepted
+func userSignalProperties(user *models.User) ([]models.Property, error) {
+	ids := append([]string{}, user.Favorites...)
+
+	var accepted []models.Recommendation
+	err := mgm.Coll(&models.Recommendation{}).SimpleFind(&accepted, bson.M{
+		"recipient_email": user.Email,
+		"status":          "accepted",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, recommendation := range accepted {
+		ids = append(ids, recommendation.PropertyID)
+	}
+
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var properties []models.Property
+	if err := mgm.Coll(&models.Property{}).SimpleFind(&properties, bson.M{"id": bson.M{"$in": ids}}); err != nil {
+		return nil, err
+	}
+	return properties, nil
+}
+
+func receivedRecommendationPropertyIDs(email string) ([]string, error) {
+	var received []models.Recommendation
+	if err := mgm.Coll(&models.Recommendation{}).SimpleFind(&received, bson.M{"recipient_email": email}); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(received))
+	for _, recommendation := range received {
+		ids = append(ids, recommendation.PropertyID)
+	}
+	return ids, nil
+}
+
+// popularFeed is the feed of users without any signal: the most favorited
+// properties, topped up with the best rated verified ones
+func popularFeed(exclude []string) ([]FeedItem, error) {
+	pipeline := bson.A{
+		bson.M{"$unwind": "$favorites"},
+		bson.M{"$match": bson.M{"favorites": bson.M{"$nin": exclude}}},
+		bson.M{"$group": bson.M{"_id": "$favorites", "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$limit": MaxFeedLimit},
+	}
+
+	cursor, err := mgm.Coll(&models.User{}).Aggregate(mgm.Ctx(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var popular []struct {
+		ID    string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cursor.All(mgm.Ctx(), &popular); err != nil {
+		return nil, err
+	}
+
+	items := make([]FeedItem, 0, MaxFeedLimit)
+	if len(popular) > 0 {
+		ids := make([]string, 0, len(popular))
+		for _, entry := range popular {
+			ids = append(ids, entry.ID)
+		}
+
+		var properties []models.Property
+		if err := mgm.Coll(&models.Property{}).SimpleFind(&properties, bson.M{"id": bson.M{"$in": ids}}); err != nil {
+			return nil, err
+		}
+		byID := make(map[string]models.Property, len(properties))
+		for _, property := range properties {
+			byID[property.ID] = property
+		}
+
+		for _, entry := range popular {
+			property, ok := byID[entry.ID]
+			if !ok {
+				continue
+			}
+			items = append(items, FeedItem{
+				Property: property,
+				Score:    float64(entry.Count),
+				Reasons:  []string{fmt.Sprintf("Popular: favorited by %d users", entry.Count)},
+			})
+			exclude = append(exclude, property.ID)
+		}
+	}
+
+	if len(items) < MaxFeedLimit {
+		opts := options.Find().
+			SetSort(bson.D{{Key: "rating", Value: -1}, {Key: "_id", Value: 1}}).
+			SetLimit(int64(MaxFeedLimit - len(items)))
+
+		var verified []models.Property
+		err := mgm.Coll(&models.Property{}).SimpleFind(&verified, bson.M{
+			"isVerified": true,
+			"id":         bson.M{"$nin": exclude},
+		}, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, property := range verified {
+			items = append(items, FeedItem{
+				Property: property,
+				Score:    property.Rating,
+				Reasons:  []string{fmt.Sprintf("Verified listing rated %.1f", property.Rating)},
+			})
+		}
+	}
+
+	return items, nil
+}
+
+func mapKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// propertyField is a property field clients can select, with its name in
+// MongoDB and in the JSON responses
+type propertyField struct {
+	bson string
+	json string
+}
+
+// selectableFields is the allow-list of fields=, keyed by lower case name
+var selectableFields = map[string]propertyField{
+	"id":            {"id", "ID"},
+	"title":         {"title", "Title"},
+	"type":          {"type", "Type"},
+	"price":         {"price", "Price"},
+	"state":         {"state", "State"},
+	"city":          {"city", "City"},
+	"areasqft":      {"areaSqFt", "AreaSqFt"},
+	"bedrooms":      {"bedrooms", "Bedrooms"},
+	"bathrooms":     {"bathrooms", "Bathrooms"},
+	"amenities":     {"amenities", "Amenities"},
+	"furnished":     {"furnished", "Furnished"},
+	"availablefrom": {"availableFrom", "AvailableFrom"},
+	"listedby":      {"listedBy", "ListedBy"},
+	"tags":          {"tags", "Tags"},
+	"colortheme":    {"colorTheme", "ColorTheme"},
+	"rating":        {"rating", "Rating"},
+	"isverified":    {"isVerified", "IsVerified"},
+	"listingtype":   {"listingType", "ListingType"},
+	"location":      {"location", "location"},
+	"created_by":    {"created_by", "created_by"},
+	"created_at":    {"created_at", "created_at"},
+	"updated_at":    {"updated_at", "updated_at"},
+}
+
+// modelJSONKeys are the keys of a property in JSON responses that belong to
+// the model. Keys outside of it, like a search score, are always kept.
+var modelJSONKeys = func() map[string]bool {
+	keys := map[string]bool{"id": true} // the MongoDB _id
+	for _, field := range selectableFields {
+		keys[field.json] = true
+	}
+	return keys
+}()
+
+// FieldSet is the subset of property fields a client asked for. A nil
+// FieldSet stands for every field.
+type FieldSet struct {
+	fields []propertyField
+}
+
+// ParseFieldSet parses a comma-separated fields= parameter, field names are
+// the ones used by the filters and are case-insensitive. An empty parameter
+// selects every field and returns nil.
+func ParseFieldSet(param string) (*FieldSet, error) {
+	if strings.TrimSpace(param) == "" {
+		return nil, nil
+	}
+
+	set := &FieldSet{}
+	seen := map[string]bool{}
+	for _, name := range strings.Split(param, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		field, ok := selectableFields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q, allowed: %s", name, strings.Join(SelectableFieldNames(), ", "))
+		}
+		seen[name] = true
+		set.fields = append(set.fields, field)
+	}
+	if len(set.fields) == 0 {
+		return nil, nil
+	}
+	return set, nil
+}
+
+// SelectableFieldNames lists the fields that can be selected
+func SelectableFieldNames() []string {
+	names := make([]string, 0, len(selectableFields))
+	for _, field := range selectableFields {
+		names = append(names, field.bson)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Projection is the MongoDB projection loading the selected fields and the
+// keys of sort, which cursors are built from. It is nil for every field.
+func (f *FieldSet) Projection(sort bson.D) bson.M {
+	if f == nil {
+		return nil
+	}
+
+	projection := bson.M{}
+	for _, field := range f.fields {
+		projection[field.bson] = 1
+	}
+	for _, e := range sort {
+		projection[e.Key] = 1
+	}
+	return projection
+}
+
+// Apply removes the fields that were not selected from a property, or each
+// of a list of properties, as they will be sent to the client
+func (f *FieldSet) Apply(data interface{}) (interface{}, error) {
+	if f == nil {
+		return data, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	selected := map[string]bool{}
+	for _, field := range f.fields {
+		selected[field.json] = true
+	}
+	trim := func(item interface{}) {
+		if object, ok := item.(map[string]interface{}); ok {
+			for key := range object {
+				if modelJSONKeys[key] && !selected[key] {
+					delete(object, key)
+				}
+			}
+		}
+	}
+
+	if list, ok := decoded.([]interface{}); ok {
+		for _, item := range list {
+			trim(item)
+		}
+	} else {
+		trim(decoded)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"property_lister/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseFieldSet(t *testing.T) {
+	tests := []struct {
+		param      string
+		projection bson.M
+		wantErr    bool
+	}{
+		{"", nil, false},
+		{" , ", nil, false},
+		{"title,price", bson.M{"title": 1, "price": 1}, false},
+		{" Title , AREASQFT,title", bson.M{"title": 1, "areaSqFt": 1}, false},
+		{"created_at,location", bson.M{"created_at": 1, "location": 1}, false},
+		{"title,password", nil, true},
+		{"_id", nil, true},
+	}
+
+	for _, tt := range tests {
+		set, err := ParseFieldSet(tt.param)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseFieldSet(%q) error = %v, want error %v", tt.param, err, tt.wantErr)
+			continue
+		}
+		if got := set.Projection(nil); !reflect.DeepEqual(got, tt.projection) {
+			t.Errorf("ParseFieldSet(%q).Projection() = %v, want %v", tt.param, got, tt.projection)
+		}
+	}
+}
+
+func TestFieldSetProjectionAddsSortKeys(t *testing.T) {
+	set, err := ParseFieldSet("title")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort := bson.D{{Key: "price", Value: -1}, {Key: "_id", Value: 1}}
+	want := bson.M{"title": 1, "price": 1, "_id": 1}
+	if got := set.Projection(sort); !reflect.DeepEqual(got, want) {
+		t.Errorf("Projection(%v) = %v, want %v", sort, got, want)
+	}
+}
+
+func TestFieldSetApply(t *testing.T) {
+	property := models.Property{ID: "PROP1", Title: "Villa", Price: 2500000, City: "Pune"}
+	result := models.PropertySearchResult{Property: property, Score: 1.5}
+
+	tests := []struct {
+		name   string
+		fields string
+		data   interface{}
+		want   string
+	}{
+		{"single property", "title,price", property,
+			`{"Title":"Villa","Price":2500000}`},
+		{"list of properties", "city", []models.Property{property, property},
+			`[{"City":"Pune"},{"City":"Pune"}]`},
+		{"extra keys are kept", "id", result,
+			`{"ID":"PROP1","score":1.5}`},
+	}
+
+	for _, tt := range tests {
+		set, err := ParseFieldSet(tt.fields)
+		if err != nil {
+			t.Fatal(err)
+		}
+		applied, err := set.Apply(tt.data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		var got, want interface{}
+		raw, _ := json.Marshal(applied)
+		json.Unmarshal(raw, &got)
+		json.Unmarshal([]byte(tt.want), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Apply = %s, want %s", tt.name, raw, tt.want)
+		}
+	}
+}
+
+func TestNilFieldSetApply(t *testing.T) {
+	var set *FieldSet
+	property := models.Property{ID: "PROP1"}
+
+	applied, err := set.Apply(property)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(applied, property) {
+		t.Errorf("nil FieldSet changed the data: %v", applied)
+	}
+}
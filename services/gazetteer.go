@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"property_lister/models"
+)
+
+var (
+	gazetteerOnce   sync.Once
+	gazetteerCities map[string]*models.GeoPoint
+	gazetteerByName map[string]*models.GeoPoint
+)
+
+func gazetteerKey(city, state string) string {
+	return strings.ToLower(strings.TrimSpace(city)) + "|" + strings.ToLower(strings.TrimSpace(state))
+}
+
+// loadGazetteer reads the offline city gazetteer, GAZETTEER_PATH
+// (default: data/cities.csv), once on first use
+func loadGazetteer() {
+	gazetteerCities = map[string]*models.GeoPoint{}
+	gazetteerByName = map[string]*models.GeoPoint{}
+
+	path := os.Getenv("GAZETTEER_PATH")
+	if path == "" {
+		path = "data/cities.csv"
+	}
+
+	if err := readGazetteer(path); err != nil {
+		log.Printf("Failed to load city gazetteer %s: %v", path, err)
+	}
+}
+
+func readGazetteer(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i, row := range records {
+		if i == 0 {
+			continue
+		}
+		if len(row) < 4 {
+			return fmt.Errorf("row %d: expected city,state,latitude,longitude", i)
+		}
+
+		lat, err := strconv.ParseFloat(row[2], 64)
+		if err != nil {
+			return fmt.Errorf("row %d: invalid latitude: %w", i, err)
+		}
+		lng, err := strconv.ParseFloat(row[3], 64)
+		if err != nil {
+			return fmt.Errorf("row %d: invalid longitude: %w", i, err)
+		}
+
+		point := models.NewGeoPoint(lat, lng)
+		gazetteerCities[gazetteerKey(row[0], row[1])] = point
+		name := strings.ToLower(strings.TrimSpace(row[0]))
+		if _, exists := gazetteerByName[name]; !exists {
+			gazetteerByName[name] = point
+		}
+	}
+
+	return nil
+}
+
+// LookupCityLocation returns the coordinates of a city from the gazetteer.
+// The state is used to disambiguate, a city that is only known under a
+// different state still matches by name.
+func LookupCityLocation(city, state string) *models.GeoPoint {
+	gazetteerOnce.Do(loadGazetteer)
+
+	point, ok := gazetteerCities[gazetteerKey(city, state)]
+	if !ok {
+		point, ok = gazetteerByName[strings.ToLower(strings.TrimSpace(city))]
+	}
+	if !ok {
+		return nil
+	}
+
+	// Hand out a copy so callers can't modify the gazetteer
+	return models.NewGeoPoint(point.Lat(), point.Lng())
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the MongoDB indexes the services rely on.
+// Creating an index that already exists is a no-op.
+func EnsureIndexes() error {
+	_, err := mgm.Coll(&models.APIKey{}).Indexes().CreateMany(mgm.Ctx(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Full-text search index, title matches weigh the most, then city, then tags
+	_, err = mgm.Coll(&models.Property{}).Indexes().CreateOne(mgm.Ctx(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "title", Value: "text"},
+			{Key: "city", Value: "text"},
+			{Key: "tags", Value: "text"},
+			{Key: "state", Value: "text"},
+			{Key: "type", Value: "text"},
+			{Key: "amenities", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("property_text_search").
+			SetDefaultLanguage("english").
+			SetWeights(bson.D{
+				{Key: "title", Value: 10},
+				{Key: "city", Value: 6},
+				{Key: "tags", Value: 4},
+				{Key: "state", Value: 3},
+				{Key: "type", Value: 2},
+				{Key: "amenities", Value: 1},
+			}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Geospatial queries on property locations
+	_, err = mgm.Coll(&models.Property{}).Indexes().CreateOne(mgm.Ctx(), mongo.IndexModel{
+		Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Saved searches and their alerts, one alert per search and property
+	_, err = mgm.Coll(&models.SavedSearch{}).Indexes().CreateOne(mgm.Ctx(), mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = mgm.Coll(&models.SearchAlert{}).Indexes().CreateMany(mgm.Ctx(), []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "saved_search_id", Value: 1}, {Key: "property_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+	})
+	return err
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"property_lister/config"
+)
+
+const (
+	// Failed attempts per account before it is temporarily locked
+	MaxAccountLoginFailures = 5
+	// Failed attempts per IP address before it is temporarily locked
+	MaxIPLoginFailures = 20
+	// Failed attempts per account after which each retry is progressively delayed
+	LoginDelayThreshold = 3
+
+	LoginFailureWindow = 15 * time.Minute
+	BaseLoginLockout   = 15 * time.Minute
+	MaxLoginLockout    = 24 * time.Hour
+)
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginFailKey(scope, subject string) string {
+	return fmt.Sprintf("login_fail:%s:%s", scope, subject)
+}
+
+func loginLockKey(scope, subject string) string {
+	return fmt.Sprintf("login_lock:%s:%s", scope, subject)
+}
+
+func loginDelayKey(email string) string {
+	return fmt.Sprintf("login_delay:account:%s", email)
+}
+
+func loginLockoutCountKey(email string) string {
+	return fmt.Sprintf("login_lockouts:account:%s", email)
+}
+
+// CheckLoginAllowed returns how long the caller has to wait before another
+// login attempt for the account or from the IP address is accepted.
+// Redis failures are logged and don't block logins.
+func CheckLoginAllowed(email, ip string) time.Duration {
+	email = normalizeLoginEmail(email)
+	keys := []string{
+		loginLockKey("account", email),
+		loginLockKey("ip", ip),
+		loginDelayKey(email),
+	}
+
+	var wait time.Duration
+	for _, key := range keys {
+		ttl, err := config.RedisClient.PTTL(config.Ctx, key).Result()
+		if err != nil {
+			log.Printf("Login guard check failed for key '%s': %v", key, err)
+			continue
+		}
+		if ttl > wait {
+			wait = ttl
+		}
+	}
+	return wait
+}
+
+// RecordLoginFailure counts a failed attempt against the account and the IP
+// address, applying progressive delays and temporary lockouts. It returns
+// the lockout duration if the account just got locked.
+func RecordLoginFailure(email, ip string) time.Duration {
+	email = normalizeLoginEmail(email)
+
+	if failures := incrementLoginFailures(loginFailKey("ip", ip)); failures >= MaxIPLoginFailures {
+		config.RedisClient.Set(config.Ctx, loginLockKey("ip", ip), "locked", BaseLoginLockout)
+		config.RedisClient.Del(config.Ctx, loginFailKey("ip", ip))
+		log.Printf("Locked IP %s after %d failed login attempts", ip, failures)
+	}
+
+	failures := incrementLoginFailures(loginFailKey("account", email))
+	if failures >= MaxAccountLoginFailures {
+		// Every lockout within a day doubles the next one
+		lockouts, _ := config.RedisClient.Incr(config.Ctx, loginLockoutCountKey(email)).Result()
+		config.RedisClient.Expire(config.Ctx, loginLockoutCountKey(email), MaxLoginLockout)
+
+		lockout := BaseLoginLockout * time.Duration(math.Pow(2, float64(max(lockouts-1, 0))))
+		if lockout > MaxLoginLockout {
+			lockout = MaxLoginLockout
+		}
+
+		config.RedisClient.Set(config.Ctx, loginLockKey("account", email), "locked", lockout)
+		config.RedisClient.Del(config.Ctx, loginFailKey("account", email), loginDelayKey(email))
+		log.Printf("Locked account %s for %s after %d failed login attempts", email, lockout, failures)
+		return lockout
+	}
+
+	if failures >= LoginDelayThreshold {
+		delay := time.Second * time.Duration(math.Pow(2, float64(failures-LoginDelayThreshold)))
+		config.RedisClient.Set(config.Ctx, loginDelayKey(email), "delayed", delay)
+	}
+
+	return 0
+}
+
+func incrementLoginFailures(key string) int64 {
+	failures, err := config.RedisClient.Incr(config.Ctx, key).Result()
+	if err != nil {
+		log.Printf("Login guard failed to count failure for key '%s': %v", key, err)
+		return 0
+	}
+	if failures == 1 {
+		config.RedisClient.Expire(config.Ctx, key, LoginFailureWindow)
+	}
+	return failures
+}
+
+// RecordLoginSuccess clears the failed attempt counter of the account
+func RecordLoginSuccess(email string) {
+	email = normalizeLoginEmail(email)
+	config.RedisClient.Del(config.Ctx, loginFailKey("account", email), loginDelayKey(email))
+}
+
+// UnlockAccount lifts any lockout, delay and failure history on the account,
+// leaving nothing keyed by its email address
+func UnlockAccount(email string) error {
+	email = normalizeLoginEmail(email)
+	return config.RedisClient.Del(config.Ctx,
+		loginLockKey("account", email),
+		loginFailKey("account", email),
+		loginDelayKey(email),
+		loginLockoutCountKey(email),
+	).Err()
+}
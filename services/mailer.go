@@ -0,0 +1,131 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Mail is a plain text email message
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing email
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+// DefaultMailer is the mailer used by the application, set up by InitMailer
+var DefaultMailer Mailer
+
+// InitMailer configures DefaultMailer from the environment. MAIL_DRIVER
+// selects "smtp" or "outbox" (default), which writes messages to disk.
+func InitMailer() {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@property-lister.local"
+	}
+
+	switch os.Getenv("MAIL_DRIVER") {
+	case "smtp":
+		DefaultMailer = &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	default:
+		dir := os.Getenv("MAIL_OUTBOX_DIR")
+		if dir == "" {
+			dir = "outbox"
+		}
+		DefaultMailer = &OutboxMailer{Dir: dir, From: from}
+	}
+}
+
+// SendMail delivers a message through DefaultMailer and logs failures.
+// It is safe to call from a goroutine.
+func SendMail(mail Mail) error {
+	if DefaultMailer == nil {
+		err := fmt.Errorf("mailer is not initialized")
+		log.Printf("Failed to send mail to '%s': %v", mail.To, err)
+		return err
+	}
+
+	if err := DefaultMailer.Send(mail); err != nil {
+		log.Printf("Failed to send mail to '%s': %v", mail.To, err)
+		return err
+	}
+	return nil
+}
+
+// AppURL builds an absolute link to the application from APP_BASE_URL
+func AppURL(path string) string {
+	base := os.Getenv("APP_BASE_URL")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return strings.TrimRight(base, "/") + path
+}
+
+func buildMessage(from string, mail Mail) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(mail.Body)
+	return []byte(b.String())
+}
+
+// SMTPMailer sends mail through an SMTP server
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(mail Mail) error {
+	port := m.Port
+	if port == "" {
+		port = "587"
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	return smtp.SendMail(m.Host+":"+port, auth, m.From, []string{mail.To}, buildMessage(m.From, mail))
+}
+
+// OutboxMailer writes each message as an .eml file into Dir instead of
+// sending it. Used for local development and tests.
+type OutboxMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *OutboxMailer) Send(mail Mail) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	recipient := strings.NewReplacer("@", "_at_", "/", "_", "\\", "_").Replace(mail.To)
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), recipient)
+
+	return os.WriteFile(filepath.Join(m.Dir, name), buildMessage(m.From, mail), 0o644)
+}
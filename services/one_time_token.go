@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"property_lister/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	PasswordResetTTL     = time.Hour
+	EmailVerificationTTL = 24 * time.Hour
+
+	oneTimeTokenPasswordReset     = "password_reset"
+	oneTimeTokenEmailVerification = "email_verify"
+)
+
+var (
+	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+)
+
+func oneTimeTokenKey(kind, tokenHash string) string {
+	return fmt.Sprintf("%s:%s", kind, tokenHash)
+}
+
+func oneTimeTokenUserKey(kind, userID string) string {
+	return fmt.Sprintf("%s_user:%s", kind, userID)
+}
+
+// issueOneTimeToken creates a single-use token of the given kind for the user.
+// Only the hash of the token is stored and any earlier token of the same kind
+// is invalidated.
+func issueOneTimeToken(kind, userID string, ttl time.Duration) (string, error) {
+	token, err := generateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	tokenHash := hashToken(token)
+
+	// Invalidate the previous token, if any
+	if previous, err := config.RedisClient.Get(config.Ctx, oneTimeTokenUserKey(kind, userID)).Result(); err == nil {
+		config.RedisClient.Del(config.Ctx, oneTimeTokenKey(kind, previous))
+	}
+
+	pipe := config.RedisClient.TxPipeline()
+	pipe.Set(config.Ctx, oneTimeTokenKey(kind, tokenHash), userID, ttl)
+	pipe.Set(config.Ctx, oneTimeTokenUserKey(kind, userID), tokenHash, ttl)
+	if _, err := pipe.Exec(config.Ctx); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// consumeOneTimeToken redeems a token and returns the user ID it was issued
+// for. The token cannot be used again afterwards.
+func consumeOneTimeToken(kind, token string) (string, error) {
+	userID, err := config.RedisClient.GetDel(config.Ctx, oneTimeTokenKey(kind, hashToken(token))).Result()
+	if err != nil {
+		return "", err
+	}
+
+	config.RedisClient.Del(config.Ctx, oneTimeTokenUserKey(kind, userID))
+	return userID, nil
+}
+
+// CreatePasswordResetToken issues a single-use password reset token
+func CreatePasswordResetToken(userID string) (string, error) {
+	return issueOneTimeToken(oneTimeTokenPasswordReset, userID, PasswordResetTTL)
+}
+
+// ConsumePasswordResetToken redeems a password reset token
+func ConsumePasswordResetToken(token string) (string, error) {
+	userID, err := consumeOneTimeToken(oneTimeTokenPasswordReset, token)
+	if err == redis.Nil {
+		return "", ErrInvalidResetToken
+	}
+	return userID, err
+}
+
+// CreateEmailVerificationToken issues a single-use email verification token
+func CreateEmailVerificationToken(userID string) (string, error) {
+	return issueOneTimeToken(oneTimeTokenEmailVerification, userID, EmailVerificationTTL)
+}
+
+// ConsumeEmailVerificationToken redeems an email verification token
+func ConsumeEmailVerificationToken(token string) (string, error) {
+	userID, err := consumeOneTimeToken(oneTimeTokenEmailVerification, token)
+	if err == redis.Nil {
+		return "", ErrInvalidVerificationToken
+	}
+	return userID, err
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor marks a position in a sorted result list for keyset pagination.
+// It holds the sort key values of the item the page continues from and
+// whether the page lies after (next) or before (previous) that item.
+type Cursor struct {
+	Values   bson.D `bson:"v"`
+	Backward bool   `bson:"b,omitempty"`
+}
+
+// WithIDTiebreaker appends _id to a sort so every item has a unique position
+func WithIDTiebreaker(sort bson.D) bson.D {
+	for _, e := range sort {
+		if e.Key == "_id" {
+			return sort
+		}
+	}
+	return append(sort, bson.E{Key: "_id", Value: 1})
+}
+
+// ReverseSort flips the direction of every sort key
+func ReverseSort(sort bson.D) bson.D {
+	reversed := make(bson.D, len(sort))
+	for i, e := range sort {
+		reversed[i] = bson.E{Key: e.Key, Value: -sortDirection(e)}
+	}
+	return reversed
+}
+
+func sortDirection(e bson.E) int {
+	switch v := e.Value.(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	}
+	return 1
+}
+
+// EncodeCursor builds the opaque cursor token for an item of a result list
+// sorted by sort. The item is anything that marshals to the stored document.
+// A missing sort key is stored as null, which MongoDB sorts it as.
+func EncodeCursor(item interface{}, sort bson.D, backward bool) (string, error) {
+	raw, err := bson.Marshal(item)
+	if err != nil {
+		return "", err
+	}
+
+	cursor := Cursor{Backward: backward}
+	for _, e := range sort {
+		var value interface{}
+		rawValue, err := bson.Raw(raw).LookupErr(e.Key)
+		switch {
+		case err == nil:
+			value = rawValue
+		case !errors.Is(err, bsoncore.ErrElementNotFound):
+			return "", err
+		}
+		cursor.Values = append(cursor.Values, bson.E{Key: e.Key, Value: value})
+	}
+
+	data, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor parses a cursor token and checks it was made for the same sort
+func DecodeCursor(token string, sort bson.D) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := bson.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if len(cursor.Values) != len(sort) {
+		return nil, ErrInvalidCursor
+	}
+	for i, e := range sort {
+		if cursor.Values[i].Key != e.Key {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	return &cursor, nil
+}
+
+// QuerySort returns the sort to query with, reversed when paging backwards
+func (c *Cursor) QuerySort(sort bson.D) bson.D {
+	if c != nil && c.Backward {
+		return ReverseSort(sort)
+	}
+	return sort
+}
+
+// Filter returns the condition selecting the items after the cursor, or
+// before it when paging backwards:
+// (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+// $gt and $lt only compare values of the same type, so nulls, which MongoDB
+// sorts before everything else, are handled apart: everything that is not
+// null comes after a null, nothing comes before it, and nulls come before
+// every other value. Equality with null also matches missing fields, like
+// the sort does.
+func (c *Cursor) Filter(sort bson.D) bson.M {
+	or := bson.A{}
+	for i, e := range sort {
+		clause := bson.M{}
+		for _, prev := range c.Values[:i] {
+			clause[prev.Key] = prev.Value
+		}
+
+		value := c.Values[i].Value
+		greater := (sortDirection(e) < 0) == c.Backward
+		switch {
+		case isNullValue(value) && greater:
+			clause[e.Key] = bson.M{"$ne": nil}
+		case isNullValue(value):
+			continue
+		case greater:
+			clause[e.Key] = bson.M{"$gt": value}
+		case e.Key == "_id":
+			// Never null
+			clause[e.Key] = bson.M{"$lt": value}
+		default:
+			clause["$or"] = bson.A{
+				bson.M{e.Key: bson.M{"$lt": value}},
+				bson.M{e.Key: nil},
+			}
+		}
+
+		or = append(or, clause)
+	}
+	return bson.M{"$or": or}
+}
+
+// isNullValue reports whether a cursor value is null
+func isNullValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bson.RawValue:
+		return v.Type == bsontype.Null || v.Type == bsontype.Undefined
+	case primitive.Null, primitive.Undefined:
+		return true
+	}
+	return false
+}
+
+// CursorPage trims a result list fetched with limit+1 items to one page,
+// restores the original order of backward pages and builds the cursors of
+// the neighbouring pages. Without a cursor the list is the first page of
+// page based pagination and hasPrev tells whether earlier pages exist.
+func CursorPage[T any](items []T, limit int, sort bson.D, cursor *Cursor, hasPrev bool) ([]T, string, string, error) {
+	hasMore := len(items) > limit
+	if hasMore {
+		items = items[:limit]
+	}
+
+	hasNext := hasMore
+	if cursor != nil {
+		// A cursor always points at an item on the side we came from
+		hasNext, hasPrev = hasMore, true
+		if cursor.Backward {
+			for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+				items[i], items[j] = items[j], items[i]
+			}
+			hasNext, hasPrev = true, hasMore
+		}
+	}
+
+	var next, prev string
+	var err error
+	if hasNext && len(items) > 0 {
+		if next, err = EncodeCursor(items[len(items)-1], sort, false); err != nil {
+			return nil, "", "", err
+		}
+	}
+	if hasPrev && len(items) > 0 {
+		if prev, err = EncodeCursor(items[0], sort, true); err != nil {
+			return nil, "", "", err
+		}
+	}
+
+	return items, next, prev, nil
+}
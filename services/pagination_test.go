@@ -0,0 +1,224 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type pageItem struct {
+	ID    primitive.ObjectID `bson:"_id"`
+	Price int                `bson:"price"`
+	Title string             `bson:"title"`
+}
+
+var priceSort = WithIDTiebreaker(bson.D{{Key: "price", Value: -1}})
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	item := pageItem{ID: id, Price: 2500000, Title: "Villa"}
+
+	for _, backward := range []bool{false, true} {
+		token, err := EncodeCursor(item, priceSort, backward)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cursor, err := DecodeCursor(token, priceSort)
+		if err != nil {
+			t.Fatalf("DecodeCursor(%q): %v", token, err)
+		}
+
+		want := bson.D{{Key: "price", Value: int32(2500000)}, {Key: "_id", Value: id}}
+		if !reflect.DeepEqual(cursor.Values, want) || cursor.Backward != backward {
+			t.Errorf("decoded cursor = %v backward %v, want %v backward %v", cursor.Values, cursor.Backward, want, backward)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsInvalidTokens(t *testing.T) {
+	token, err := EncodeCursor(pageItem{ID: primitive.NewObjectID(), Price: 1}, priceSort, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		sort  bson.D
+	}{
+		{"not base64", "not a cursor!", priceSort},
+		{"not bson", "aGVsbG8", priceSort},
+		{"other sort keys", token, WithIDTiebreaker(bson.D{{Key: "title", Value: 1}})},
+		{"fewer sort keys", token, bson.D{{Key: "price", Value: -1}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := DecodeCursor(tt.token, tt.sort); err != ErrInvalidCursor {
+			t.Errorf("%s: DecodeCursor error = %v, want ErrInvalidCursor", tt.name, err)
+		}
+	}
+}
+
+func TestEncodeCursorMissingSortKey(t *testing.T) {
+	sort := WithIDTiebreaker(bson.D{{Key: "rating", Value: -1}})
+	token, err := EncodeCursor(pageItem{}, sort, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cursor, err := DecodeCursor(token, sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cursor.Values[0].Value != nil {
+		t.Errorf("missing sort key decoded as %v, want null", cursor.Values[0].Value)
+	}
+}
+
+func TestCursorFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	values := bson.D{{Key: "price", Value: 100}, {Key: "_id", Value: id}}
+
+	tests := []struct {
+		backward bool
+		want     bson.M
+	}{
+		// Nulls sort before every price
+		{false, bson.M{"$or": bson.A{
+			bson.M{"$or": bson.A{bson.M{"price": bson.M{"$lt": 100}}, bson.M{"price": nil}}},
+			bson.M{"price": 100, "_id": bson.M{"$gt": id}},
+		}}},
+		{true, bson.M{"$or": bson.A{
+			bson.M{"price": bson.M{"$gt": 100}},
+			bson.M{"price": 100, "_id": bson.M{"$lt": id}},
+		}}},
+	}
+
+	for _, tt := range tests {
+		cursor := &Cursor{Values: values, Backward: tt.backward}
+		if got := cursor.Filter(priceSort); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("backward %v: Filter = %v, want %v", tt.backward, got, tt.want)
+		}
+	}
+}
+
+func TestCursorQuerySort(t *testing.T) {
+	var none *Cursor
+	if got := none.QuerySort(priceSort); !reflect.DeepEqual(got, priceSort) {
+		t.Errorf("QuerySort without cursor = %v, want %v", got, priceSort)
+	}
+
+	backward := &Cursor{Backward: true}
+	want := bson.D{{Key: "price", Value: 1}, {Key: "_id", Value: -1}}
+	if got := backward.QuerySort(priceSort); !reflect.DeepEqual(got, want) {
+		t.Errorf("QuerySort backward = %v, want %v", got, want)
+	}
+}
+
+func TestCursorPage(t *testing.T) {
+	items := make([]pageItem, 4)
+	for i := range items {
+		items[i] = pageItem{ID: primitive.NewObjectID(), Price: 400 - i*100}
+	}
+
+	tests := []struct {
+		name       string
+		items      []pageItem
+		cursor     *Cursor
+		hasPrev    bool
+		wantPrices []int
+		wantNext   bool
+		wantPrev   bool
+	}{
+		{"first page with more", items[:3], nil, false, []int{400, 300}, true, false},
+		{"last page", items[:2], nil, true, []int{400, 300}, false, true},
+		{"forward cursor with more", items[1:4], &Cursor{}, false, []int{300, 200}, true, true},
+		{"forward cursor at the end", items[2:4], &Cursor{}, false, []int{200, 100}, false, true},
+		// Backward pages are fetched in reverse order
+		{"backward cursor with more", []pageItem{items[2], items[1], items[0]}, &Cursor{Backward: true}, false, []int{300, 200}, true, true},
+		{"backward cursor at the start", []pageItem{items[1], items[0]}, &Cursor{Backward: true}, false, []int{400, 300}, true, false},
+	}
+
+	for _, tt := range tests {
+		input := append([]pageItem{}, tt.items...)
+		page, next, prev, err := CursorPage(input, 2, priceSort, tt.cursor, tt.hasPrev)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		prices := []int{}
+		for _, item := range page {
+			prices = append(prices, item.Price)
+		}
+		if !reflect.DeepEqual(prices, tt.wantPrices) {
+			t.Errorf("%s: prices = %v, want %v", tt.name, prices, tt.wantPrices)
+		}
+		if (next != "") != tt.wantNext || (prev != "") != tt.wantPrev {
+			t.Errorf("%s: next %q prev %q, want next %v prev %v", tt.name, next, prev, tt.wantNext, tt.wantPrev)
+		}
+
+		if next != "" {
+			cursor, err := DecodeCursor(next, priceSort)
+			if err != nil || cursor.Backward || cursor.Values[0].Value != int32(page[len(page)-1].Price) {
+				t.Errorf("%s: next cursor %v (%v) does not continue after the last item", tt.name, cursor, err)
+			}
+		}
+		if prev != "" {
+			cursor, err := DecodeCursor(prev, priceSort)
+			if err != nil || !cursor.Backward || cursor.Values[0].Value != int32(page[0].Price) {
+				t.Errorf("%s: prev cursor %v (%v) does not go back from the first item", tt.name, cursor, err)
+			}
+		}
+	}
+}
+
+func TestCursorFilterNullValue(t *testing.T) {
+	type availableItem struct {
+		ID            primitive.ObjectID  `bson:"_id"`
+		AvailableFrom *primitive.DateTime `bson:"availableFrom"`
+	}
+	id := primitive.NewObjectID()
+
+	tests := []struct {
+		name     string
+		order    int
+		backward bool
+		want     bson.M
+	}{
+		// Ascending, nulls come first: after a null come the remaining
+		// nulls and every date, before it only nulls
+		{"ascending next", 1, false, bson.M{"$or": bson.A{
+			bson.M{"availableFrom": bson.M{"$ne": nil}},
+			bson.M{"availableFrom": nil, "_id": bson.M{"$gt": id}},
+		}}},
+		{"ascending previous", 1, true, bson.M{"$or": bson.A{
+			bson.M{"availableFrom": nil, "_id": bson.M{"$lt": id}},
+		}}},
+		// Descending, nulls come last
+		{"descending next", -1, false, bson.M{"$or": bson.A{
+			bson.M{"availableFrom": nil, "_id": bson.M{"$gt": id}},
+		}}},
+		{"descending previous", -1, true, bson.M{"$or": bson.A{
+			bson.M{"availableFrom": bson.M{"$ne": nil}},
+			bson.M{"availableFrom": nil, "_id": bson.M{"$lt": id}},
+		}}},
+	}
+
+	for _, tt := range tests {
+		sort := WithIDTiebreaker(bson.D{{Key: "availableFrom", Value: tt.order}})
+		token, err := EncodeCursor(availableItem{ID: id}, sort, tt.backward)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cursor, err := DecodeCursor(token, sort)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := cursor.Filter(sort); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Filter = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
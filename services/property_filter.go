@@ -0,0 +1,307 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"property_lister/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DefaultNearRadiusKm = 25
+	MaxNearRadiusKm     = 1000
+	maxPolygonPoints    = 100
+	// Equatorial radius used by MongoDB to convert $centerSphere radians
+	earthRadiusKm = 6378.1
+)
+
+// QueryParams gives access to query string values, *fiber.Ctx satisfies it
+type QueryParams interface {
+	Query(key string, defaultValue ...string) string
+}
+
+// NearQuery restricts results to a radius around a point
+type NearQuery struct {
+	Point    *models.GeoPoint
+	RadiusKm float64
+}
+
+// PropertyQuery is the MongoDB filter built from property query parameters.
+// Near is set for near-point queries, whose results are ordered by distance.
+type PropertyQuery struct {
+	Filter bson.M
+	Near   *NearQuery
+}
+
+// BuildPropertyFilter turns the filter parameters of GET /api/properties
+// into a MongoDB filter. Malformed scalar filters are ignored as before,
+// malformed geo filters are reported as errors.
+func BuildPropertyFilter(q QueryParams) (*PropertyQuery, error) {
+	filter := bson.M{}
+
+	// Price range filter
+	addIntRange(filter, "price", "$gte", q.Query("min_price"))
+	addIntRange(filter, "price", "$lte", q.Query("max_price"))
+
+	// Location, type, listing type and furnished filters (case-insensitive).
+	// Each takes a comma separated list of values, values starting with "!"
+	// are excluded.
+	for param, field := range map[string]string{
+		"state":        "state",
+		"city":         "city",
+		"type":         "type",
+		"listing_type": "listingType",
+		"furnished":    "furnished",
+	} {
+		if condition := valueListFilter(q.Query(param), false); condition != nil {
+			filter[field] = condition
+		}
+	}
+
+	// Amenities and tags filters (case-insensitive). A list prefixed with
+	// "all:" requires every value, "any:" or no prefix at least one.
+	for _, field := range []string{"amenities", "tags"} {
+		value := q.Query(field)
+		matchAll := strings.HasPrefix(value, "all:")
+		value = strings.TrimPrefix(strings.TrimPrefix(value, "all:"), "any:")
+		if condition := valueListFilter(value, matchAll); condition != nil {
+			filter[field] = condition
+		}
+	}
+
+	// Title filter (case-insensitive, matches anywhere in the title)
+	if title := q.Query("title"); title != "" {
+		filter["title"] = bson.M{"$regex": regexp.QuoteMeta(title), "$options": "i"}
+	}
+
+	// Bedrooms filter, exact or as a range
+	addIntRange(filter, "bedrooms", "$eq", q.Query("bedrooms"))
+	addIntRange(filter, "bedrooms", "$gte", q.Query("min_bedrooms"))
+	addIntRange(filter, "bedrooms", "$lte", q.Query("max_bedrooms"))
+
+	// Bathrooms filter
+	addIntRange(filter, "bathrooms", "$eq", q.Query("bathrooms"))
+
+	// Area filter (square feet range)
+	addIntRange(filter, "areaSqFt", "$gte", q.Query("min_area"))
+	addIntRange(filter, "areaSqFt", "$lte", q.Query("max_area"))
+
+	// Minimum rating filter
+	if minRating := q.Query("min_rating"); minRating != "" {
+		if rating, err := strconv.ParseFloat(minRating, 64); err == nil {
+			addRange(filter, "rating", "$gte", rating)
+		}
+	}
+
+	// Availability filters (YYYY-MM-DD, inclusive). available_by is the
+	// same as available_before, available_now means available by today.
+	for param, op := range map[string]string{
+		"available_after":  "$gte",
+		"available_before": "$lte",
+		"available_by":     "$lte",
+	} {
+		if date, err := models.ParseDate(q.Query(param)); err == nil {
+			addRange(filter, "availableFrom", op, date)
+		}
+	}
+	if q.Query("available_now") == "true" {
+		addRange(filter, "availableFrom", "$lte", models.Today())
+	}
+
+	// Verified filter
+	if verified := q.Query("verified"); verified != "" {
+		if verified == "true" {
+			filter["isVerified"] = true
+		} else if verified == "false" {
+			filter["isVerified"] = false
+		}
+	}
+
+	query := &PropertyQuery{Filter: filter}
+	if err := applyGeoFilter(q, query); err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
+
+// addRange adds a comparison to the conditions on a field
+func addRange(filter bson.M, field, op string, value interface{}) {
+	if existing, ok := filter[field].(bson.M); ok {
+		existing[op] = value
+	} else {
+		filter[field] = bson.M{op: value}
+	}
+}
+
+// addIntRange adds a comparison with an integer parameter, ignoring
+// values that aren't integers
+func addIntRange(filter bson.M, field, op, param string) {
+	if param == "" {
+		return
+	}
+	if value, err := strconv.Atoi(param); err == nil {
+		addRange(filter, field, op, value)
+	}
+}
+
+// valueListFilter builds the condition for a comma separated list of values,
+// each matching any value that contains it, case-insensitively, as the single
+// value filters always did. Values prefixed with "!" are excluded. The
+// remaining values have to match at least once, or all of them with matchAll,
+// which is meant for array fields.
+func valueListFilter(param string, matchAll bool) bson.M {
+	var include, exclude bson.A
+	for _, value := range strings.Split(param, ",") {
+		value = strings.TrimSpace(value)
+		excluded := strings.HasPrefix(value, "!")
+		value = strings.TrimSpace(strings.TrimPrefix(value, "!"))
+		if value == "" {
+			continue
+		}
+
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(value), Options: "i"}
+		if excluded {
+			exclude = append(exclude, pattern)
+		} else {
+			include = append(include, pattern)
+		}
+	}
+
+	condition := bson.M{}
+	if len(include) > 0 {
+		if matchAll {
+			condition["$all"] = include
+		} else {
+			condition["$in"] = include
+		}
+	}
+	if len(exclude) > 0 {
+		condition["$nin"] = exclude
+	}
+	if len(condition) == 0 {
+		return nil
+	}
+	return condition
+}
+
+// applyGeoFilter adds the near, bbox or polygon filter, only one of which
+// may be given per query
+func applyGeoFilter(q QueryParams, query *PropertyQuery) error {
+	near, bbox, polygon := q.Query("near"), q.Query("bbox"), q.Query("polygon")
+
+	given := 0
+	for _, param := range []string{near, bbox, polygon} {
+		if param != "" {
+			given++
+		}
+	}
+	if given > 1 {
+		return fmt.Errorf("only one of near, bbox and polygon can be used at a time")
+	}
+
+	switch {
+	case near != "":
+		lat, lng, err := parseLatLng(near)
+		if err != nil {
+			return fmt.Errorf("near: %w", err)
+		}
+
+		radius := float64(DefaultNearRadiusKm)
+		if radiusParam := q.Query("radius_km"); radiusParam != "" {
+			radius, err = strconv.ParseFloat(radiusParam, 64)
+			if err != nil || radius <= 0 || radius > MaxNearRadiusKm {
+				return fmt.Errorf("radius_km must be a number between 0 and %d", MaxNearRadiusKm)
+			}
+		}
+
+		query.Near = &NearQuery{Point: models.NewGeoPoint(lat, lng), RadiusKm: radius}
+		query.Filter["location"] = bson.M{"$geoWithin": bson.M{
+			"$centerSphere": bson.A{bson.A{lng, lat}, radius / earthRadiusKm},
+		}}
+
+	case bbox != "":
+		parts := strings.Split(bbox, ",")
+		if len(parts) != 4 {
+			return fmt.Errorf("bbox must be south,west,north,east")
+		}
+		south, west, err := parseLatLng(parts[0] + "," + parts[1])
+		if err != nil {
+			return fmt.Errorf("bbox: %w", err)
+		}
+		north, east, err := parseLatLng(parts[2] + "," + parts[3])
+		if err != nil {
+			return fmt.Errorf("bbox: %w", err)
+		}
+		if south >= north || west >= east {
+			return fmt.Errorf("bbox must be south,west,north,east with south < north and west < east")
+		}
+
+		ring := bson.A{
+			bson.A{west, south},
+			bson.A{east, south},
+			bson.A{east, north},
+			bson.A{west, north},
+			bson.A{west, south},
+		}
+		query.Filter["location"] = geoWithinPolygon(ring)
+
+	case polygon != "":
+		points := strings.Split(polygon, ";")
+		if len(points) < 3 || len(points) > maxPolygonPoints {
+			return fmt.Errorf("polygon needs between 3 and %d lat,lng points separated by ';'", maxPolygonPoints)
+		}
+
+		ring := bson.A{}
+		for _, point := range points {
+			lat, lng, err := parseLatLng(point)
+			if err != nil {
+				return fmt.Errorf("polygon: %w", err)
+			}
+			ring = append(ring, bson.A{lng, lat})
+		}
+
+		// GeoJSON rings have to be closed
+		first, last := ring[0].(bson.A), ring[len(ring)-1].(bson.A)
+		if first[0] != last[0] || first[1] != last[1] {
+			ring = append(ring, first)
+		}
+		if len(ring) < 4 {
+			return fmt.Errorf("polygon needs at least 3 distinct points")
+		}
+		query.Filter["location"] = geoWithinPolygon(ring)
+	}
+
+	return nil
+}
+
+func geoWithinPolygon(ring bson.A) bson.M {
+	return bson.M{"$geoWithin": bson.M{"$geometry": bson.M{
+		"type":        "Polygon",
+		"coordinates": bson.A{ring},
+	}}}
+}
+
+// parseLatLng parses a "lat,lng" pair
+func parseLatLng(value string) (float64, float64, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected lat,lng but got %q", value)
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("invalid latitude %q", parts[0])
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return 0, 0, fmt.Errorf("invalid longitude %q", parts[1])
+	}
+
+	return lat, lng, nil
+}
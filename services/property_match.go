@@ -0,0 +1,283 @@
+package services
+
+import (
+	"math"
+	"regexp"
+
+	"property_lister/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MatchesProperty reports whether the property matches the filter of the
+// query, without a round trip to MongoDB. It understands the conditions
+// BuildPropertyFilter builds and nothing else: filters with anything it
+// doesn't know never match.
+func (q *PropertyQuery) MatchesProperty(property *models.Property) bool {
+	for field, condition := range q.Filter {
+		if !fieldMatches(property, field, condition) {
+			return false
+		}
+	}
+	return true
+}
+
+// propertyFieldValue returns the value of a property field by its name in
+// MongoDB, as the filter conditions compare it
+func propertyFieldValue(p *models.Property, field string) (interface{}, bool) {
+	switch field {
+	case "price":
+		return p.Price, true
+	case "state":
+		return p.State, true
+	case "city":
+		return p.City, true
+	case "type":
+		return p.Type, true
+	case "listingType":
+		return p.ListingType, true
+	case "furnished":
+		return p.Furnished, true
+	case "amenities":
+		return p.Amenities, true
+	case "tags":
+		return p.Tags, true
+	case "title":
+		return p.Title, true
+	case "bedrooms":
+		return p.Bedrooms, true
+	case "bathrooms":
+		return p.Bathrooms, true
+	case "areaSqFt":
+		return p.AreaSqFt, true
+	case "rating":
+		return p.Rating, true
+	case "availableFrom":
+		return p.AvailableFrom, true
+	case "isVerified":
+		return p.IsVerified, true
+	case "location":
+		return p.Location, true
+	}
+	return nil, false
+}
+
+func fieldMatches(p *models.Property, field string, condition interface{}) bool {
+	value, ok := propertyFieldValue(p, field)
+	if !ok {
+		return false
+	}
+
+	operators, ok := condition.(bson.M)
+	if !ok {
+		// Plain equality, like isVerified
+		return value == condition
+	}
+
+	for op, operand := range operators {
+		var matched bool
+		switch op {
+		case "$eq", "$gte", "$lte":
+			matched = compareMatches(value, op, operand)
+		case "$in", "$all", "$nin":
+			matched = regexListMatches(stringValues(value), op, operand)
+		case "$regex":
+			options, _ := operators["$options"].(string)
+			matched = anyMatches(stringValues(value), regexFlags(options)+asString(operand))
+		case "$options":
+			matched = true
+		case "$geoWithin":
+			point, _ := value.(*models.GeoPoint)
+			matched = geoWithinMatches(point, operand)
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// compareMatches compares a number or a date with the operand of $eq, $gte
+// or $lte. Like in MongoDB, a missing date matches no comparison.
+func compareMatches(value interface{}, op string, operand interface{}) bool {
+	var cmp int
+	switch v := value.(type) {
+	case int:
+		o, ok := operand.(int)
+		if !ok {
+			return false
+		}
+		cmp = compareFloats(float64(v), float64(o))
+	case float64:
+		o, ok := operand.(float64)
+		if !ok {
+			return false
+		}
+		cmp = compareFloats(v, o)
+	case models.Date:
+		o, ok := operand.(models.Date)
+		if !ok || v.IsZero() {
+			return false
+		}
+		cmp = v.Compare(o.Time)
+	default:
+		return false
+	}
+
+	switch op {
+	case "$eq":
+		return cmp == 0
+	case "$gte":
+		return cmp >= 0
+	default:
+		return cmp <= 0
+	}
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// regexListMatches evaluates $in, $all or $nin with a list of patterns,
+// as built by valueListFilter, against the values of a field
+func regexListMatches(values []string, op string, operand interface{}) bool {
+	patterns, ok := operand.(bson.A)
+	if !ok {
+		return false
+	}
+
+	for _, pattern := range patterns {
+		regex, ok := pattern.(primitive.Regex)
+		if !ok {
+			return false
+		}
+		matched := anyMatches(values, regexFlags(regex.Options)+regex.Pattern)
+		switch {
+		case op == "$in" && matched:
+			return true
+		case op == "$all" && !matched, op == "$nin" && matched:
+			return false
+		}
+	}
+	return op != "$in"
+}
+
+// anyMatches reports whether one of the values matches the pattern
+func anyMatches(values []string, pattern string) bool {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false
+	}
+	for _, value := range values {
+		if re.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// regexFlags turns the "i" MongoDB regex option into its Go flag
+func regexFlags(options string) string {
+	if options == "i" {
+		return "(?i)"
+	}
+	return ""
+}
+
+// stringValues returns a string field, or the elements of a list field
+func stringValues(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	}
+	return nil
+}
+
+func asString(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// geoWithinMatches evaluates the $centerSphere and polygon $geoWithin
+// conditions of applyGeoFilter. Properties without a location never match.
+func geoWithinMatches(point *models.GeoPoint, operand interface{}) bool {
+	if point == nil || len(point.Coordinates) != 2 {
+		return false
+	}
+	shape, ok := operand.(bson.M)
+	if !ok {
+		return false
+	}
+
+	if sphere, ok := shape["$centerSphere"].(bson.A); ok && len(sphere) == 2 {
+		center, ok := sphere[0].(bson.A)
+		radians, radiusOK := sphere[1].(float64)
+		if !ok || !radiusOK || len(center) != 2 {
+			return false
+		}
+		lng, lngOK := center[0].(float64)
+		lat, latOK := center[1].(float64)
+		if !lngOK || !latOK {
+			return false
+		}
+		return angularDistance(lat, lng, point.Lat(), point.Lng()) <= radians
+	}
+
+	if geometry, ok := shape["$geometry"].(bson.M); ok {
+		rings, ok := geometry["coordinates"].(bson.A)
+		if !ok || len(rings) != 1 {
+			return false
+		}
+		ring, ok := rings[0].(bson.A)
+		if !ok {
+			return false
+		}
+		return ringContains(ring, point.Lng(), point.Lat())
+	}
+
+	return false
+}
+
+// angularDistance is the great-circle distance between two points in
+// radians, as $centerSphere measures it
+func angularDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// ringContains tests whether a closed ring of [lng, lat] points contains
+// the point. Edges are taken as straight lines in lng/lat, which is close
+// to MongoDB's geodesic edges for areas the size of a city.
+func ringContains(ring bson.A, lng, lat float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, aOK := ring[i].(bson.A)
+		b, bOK := ring[j].(bson.A)
+		if !aOK || !bOK || len(a) != 2 || len(b) != 2 {
+			return false
+		}
+		aLng, _ := a[0].(float64)
+		aLat, _ := a[1].(float64)
+		bLng, _ := b[0].(float64)
+		bLat, _ := b[1].(float64)
+
+		if (aLat > lat) != (bLat > lat) &&
+			lng < (bLng-aLng)*(lat-aLat)/(bLat-aLat)+aLng {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+
+	"property_lister/models"
+)
+
+func TestPropertyQueryMatchesProperty(t *testing.T) {
+	date, _ := models.ParseDate("2025-06-01")
+	property := &models.Property{
+		Title:         "Sunny flat near the lake",
+		Type:          "Apartment",
+		Price:         5000000,
+		State:         "Maharashtra",
+		City:          "Navi Mumbai",
+		Bedrooms:      2,
+		AreaSqFt:      900,
+		Amenities:     []string{"swimming pool", "gym"},
+		Tags:          []string{"lake-view"},
+		Rating:        4.2,
+		IsVerified:    true,
+		AvailableFrom: date,
+		Location:      models.NewGeoPoint(19.02, 73.05),
+	}
+
+	tests := []struct {
+		params map[string]string
+		want   bool
+	}{
+		{map[string]string{"min_price": "4000000", "max_price": "6000000"}, true},
+		{map[string]string{"max_price": "4000000"}, false},
+		{map[string]string{"city": "mumbai"}, true},
+		{map[string]string{"city": "!Mumbai"}, false},
+		{map[string]string{"city": "Pune,Delhi"}, false},
+		{map[string]string{"amenities": "pool"}, true},
+		{map[string]string{"amenities": "all:pool,gym"}, true},
+		{map[string]string{"amenities": "all:pool,lift"}, false},
+		{map[string]string{"amenities": "lift,gym"}, true},
+		{map[string]string{"title": "LAKE"}, true},
+		{map[string]string{"title": "sea"}, false},
+		{map[string]string{"bedrooms": "2"}, true},
+		{map[string]string{"min_bedrooms": "3"}, false},
+		{map[string]string{"min_rating": "4"}, true},
+		{map[string]string{"min_rating": "4.5"}, false},
+		{map[string]string{"available_by": "2025-06-01"}, true},
+		{map[string]string{"available_after": "2025-07-01"}, false},
+		{map[string]string{"verified": "false"}, false},
+		{map[string]string{"near": "19.0,73.0", "radius_km": "10"}, true},
+		{map[string]string{"near": "18.5,73.8", "radius_km": "10"}, false},
+		{map[string]string{"bbox": "19,73,19.1,73.1"}, true},
+		{map[string]string{"polygon": "19,73;19.1,73;19.1,73.1"}, false},
+		{map[string]string{"polygon": "19,73;19.1,73;19.1,73.1;19,73.1"}, true},
+	}
+
+	for _, tt := range tests {
+		query, err := BuildPropertyFilter(QueryMap(tt.params))
+		if err != nil {
+			t.Fatalf("BuildPropertyFilter(%v): %v", tt.params, err)
+		}
+		if got := query.MatchesProperty(property); got != tt.want {
+			t.Errorf("MatchesProperty(%v) = %v, want %v", tt.params, got, tt.want)
+		}
+	}
+}
+
+func TestPropertyQueryMatchesPropertyWithoutLocation(t *testing.T) {
+	query, err := BuildPropertyFilter(QueryMap{"near": "19.0,73.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.MatchesProperty(&models.Property{}) {
+		t.Error("property without a location matched a near filter")
+	}
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SavedSearchParams lists the GET /api/properties parameters a saved search can hold
+var SavedSearchParams = []string{
+	"min_price", "max_price", "state", "city", "type", "listing_type", "furnished",
+	"amenities", "tags", "title", "bedrooms", "min_bedrooms", "max_bedrooms", "bathrooms",
+	"min_area", "max_area", "min_rating", "available_after", "available_before",
+	"available_by", "available_now", "verified", "near", "radius_km", "bbox", "polygon",
+}
+
+// QueryMap serves query parameters from a map, so stored parameters can be
+// passed to BuildPropertyFilter like a request
+type QueryMap map[string]string
+
+func (m QueryMap) Query(key string, defaultValue ...string) string {
+	if value, ok := m[key]; ok && value != "" {
+		return value
+	}
+	if len(defaultValue) > 0 {
+		return defaultValue[0]
+	}
+	return ""
+}
+
+// ValidateSavedSearchParams checks that params only holds known filters
+// and builds a valid property filter
+func ValidateSavedSearchParams(params map[string]string) error {
+	if len(params) == 0 {
+		return fmt.Errorf("at least one filter is required")
+	}
+
+	known := map[string]bool{}
+	for _, name := range SavedSearchParams {
+		known[name] = true
+	}
+	for name := range params {
+		if !known[name] {
+			return fmt.Errorf("unknown filter %q", name)
+		}
+	}
+
+	_, err := BuildPropertyFilter(QueryMap(params))
+	return err
+}
+
+// MatchSavedSearches raises an alert on every saved search the property
+// matches, except the searches of the property's own creator. Each search
+// alerts about a property once, however often it's updated. The searches are
+// read in a single query and matched in memory. Meant to be run in the
+// background after a listing is created or updated.
+func MatchSavedSearches(property *models.Property) {
+	filter := bson.M{}
+	if creatorID, err := primitive.ObjectIDFromHex(property.CreatedBy); err == nil {
+		filter["user_id"] = bson.M{"$ne": creatorID}
+	}
+
+	cursor, err := mgm.Coll(&models.SavedSearch{}).Find(mgm.Ctx(), filter)
+	if err != nil {
+		log.Printf("Failed to load saved searches to match %s: %v", property.ID, err)
+		return
+	}
+	defer cursor.Close(mgm.Ctx())
+
+	for cursor.Next(mgm.Ctx()) {
+		var search models.SavedSearch
+		if err := cursor.Decode(&search); err != nil {
+			continue
+		}
+		query, err := BuildPropertyFilter(QueryMap(search.Params))
+		if err != nil || !query.MatchesProperty(property) {
+			continue
+		}
+
+		created, err := createSearchAlert(&search, property)
+		if err != nil {
+			log.Printf("Failed to create alert for saved search %s: %v", search.ID.Hex(), err)
+			continue
+		}
+		if created && search.NotifyEmail {
+			sendSearchAlertEmail(&search, property)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		log.Printf("Failed to read saved searches to match %s: %v", property.ID, err)
+	}
+}
+
+// createSearchAlert stores the alert unless the search already alerted about
+// the property and reports whether a new alert was created
+func createSearchAlert(search *models.SavedSearch, property *models.Property) (bool, error) {
+	now := time.Now()
+	result, err := mgm.Coll(&models.SearchAlert{}).UpdateOne(mgm.Ctx(),
+		bson.M{"saved_search_id": search.ID, "property_id": property.ID},
+		bson.M{"$setOnInsert": bson.M{
+			"user_id":           search.UserID,
+			"saved_search_name": search.Name,
+			"property_title":    property.Title,
+			"read":              false,
+			"created_at":        now,
+			"updated_at":        now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.UpsertedCount == 1, nil
+}
+
+func sendSearchAlertEmail(search *models.SavedSearch, property *models.Property) {
+	var user models.User
+	if err := mgm.Coll(&user).FindOne(mgm.Ctx(), bson.M{"_id": search.UserID}).Decode(&user); err != nil {
+		return
+	}
+	if !user.EmailVerified {
+		return
+	}
+
+	SendMail(Mail{
+		To:      user.Email,
+		Subject: fmt.Sprintf("New match for your saved search \"%s\"", search.Name),
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nA property matching your saved search \"%s\" was just listed or updated:\n\n%s\n%s in %s, %s for %d\n\n%s\n",
+			user.FirstName,
+			search.Name,
+			property.Title,
+			property.Type,
+			property.City,
+			property.ListingType,
+			property.Price,
+			AppURL("/api/properties/"+property.ID),
+		),
+	})
+}
+
+// DeleteSearchAlertsForProperties removes the alerts about deleted properties
+func DeleteSearchAlertsForProperties(propertyIDs []string) error {
+	_, err := mgm.Coll(&models.SearchAlert{}).DeleteMany(mgm.Ctx(), bson.M{
+		"property_id": bson.M{"$in": propertyIDs},
+	})
+	return err
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"encoding/csv"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Upper bound on the words of a query once corrections and synonyms are added
+const maxExpandedSearchTerms = 40
+
+// How long the vocabulary used to correct typos is kept in memory
+const searchVocabularyExpiry = 10 * time.Minute
+
+var (
+	synonymsOnce sync.Once
+	// synonymGroups maps every synonym, as lower case words joined by a
+	// space, to all the members of its group
+	synonymGroups  map[string][]string
+	maxSynonymSize int // words in the longest synonym
+)
+
+var searchVocabulary struct {
+	sync.Mutex
+	words    map[string]int
+	loadedAt time.Time
+}
+
+// loadSynonyms reads the synonym table, SYNONYMS_PATH (default:
+// data/synonyms.csv), once on first use. Every row is a group of terms that
+// mean the same thing.
+func loadSynonyms() {
+	synonymGroups = map[string][]string{}
+
+	path := os.Getenv("SYNONYMS_PATH")
+	if path == "" {
+		path = "data/synonyms.csv"
+	}
+
+	if err := readSynonyms(path); err != nil {
+		log.Printf("Failed to load search synonyms %s: %v", path, err)
+	}
+}
+
+func readSynonyms(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+
+	for i, row := range records {
+		if i == 0 {
+			continue
+		}
+
+		group := []string{}
+		for _, term := range row {
+			words := searchWords(strings.ToLower(term))
+			if len(words) == 0 {
+				continue
+			}
+			group = append(group, strings.Join(words, " "))
+			maxSynonymSize = max(maxSynonymSize, len(words))
+		}
+		for _, term := range group {
+			synonymGroups[term] = append(synonymGroups[term], group...)
+		}
+	}
+
+	return nil
+}
+
+// InvalidateSearchVocabulary makes the next search reload the words typos
+// are corrected to
+func InvalidateSearchVocabulary() {
+	searchVocabulary.Lock()
+	searchVocabulary.words = nil
+	searchVocabulary.Unlock()
+}
+
+// vocabularyWords returns the words of the search suggestion dictionary, of
+// the property titles and of the synonym table, with how many properties use
+// each of them
+func vocabularyWords() map[string]int {
+	searchVocabulary.Lock()
+	defer searchVocabulary.Unlock()
+
+	if searchVocabulary.words != nil && time.Since(searchVocabulary.loadedAt) < searchVocabularyExpiry {
+		return searchVocabulary.words
+	}
+
+	words := map[string]int{}
+	var suggestions []models.Suggestion
+	loaded := true
+	if err := mgm.Coll(&models.Suggestion{}).SimpleFind(&suggestions, bson.M{}); err != nil {
+		// Go on with the synonyms only and try again on the next search
+		log.Printf("Failed to load search vocabulary: %v", err)
+		loaded = false
+	}
+	for _, suggestion := range suggestions {
+		for _, word := range searchWords(suggestion.Normalized) {
+			words[word] += suggestion.Count
+		}
+	}
+
+	titleWords, err := titleWordCounts()
+	if err != nil {
+		log.Printf("Failed to load title words of the search vocabulary: %v", err)
+		loaded = false
+	}
+	for word, count := range titleWords {
+		words[word] += count
+	}
+
+	synonymsOnce.Do(loadSynonyms)
+	for term := range synonymGroups {
+		for _, word := range searchWords(term) {
+			if _, ok := words[word]; !ok {
+				words[word] = 0
+			}
+		}
+	}
+
+	if loaded {
+		searchVocabulary.words = words
+		searchVocabulary.loadedAt = time.Now()
+	}
+	return words
+}
+
+// titleWordCounts counts the properties using each word of the titles, which
+// the suggestion dictionary leaves out but the full-text index covers
+func titleWordCounts() (map[string]int, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"title": bson.M{"$type": "string"}}},
+		bson.M{"$project": bson.M{"_id": 0, "words": bson.M{"$split": bson.A{bson.M{"$toLower": "$title"}, " "}}}},
+		bson.M{"$unwind": "$words"},
+		bson.M{"$group": bson.M{"_id": "$words", "count": bson.M{"$sum": 1}}},
+	}
+
+	cursor, err := mgm.Coll(&models.Property{}).Aggregate(mgm.Ctx(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []struct {
+		Token string `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cursor.All(mgm.Ctx(), &tokens); err != nil {
+		return nil, err
+	}
+
+	// Titles are only split on spaces above, punctuation is dropped here
+	words := map[string]int{}
+	for _, token := range tokens {
+		for _, word := range searchWords(token.Token) {
+			words[word] += token.Count
+		}
+	}
+	return words, nil
+}
+
+// termHasHits reports whether the full-text index finds any property for the
+// term, which is then not taken for a typo
+func termHasHits(term string) bool {
+	count, err := mgm.Coll(&models.Property{}).CountDocuments(mgm.Ctx(),
+		bson.M{"$text": bson.M{"$search": term}},
+		options.Count().SetLimit(1),
+	)
+	return err == nil && count > 0
+}
+
+// ExpandSearchQuery makes the terms of a query tolerant to typos and
+// synonyms. A misspelled term without any hit is corrected to the closest
+// known word, then every term or run of terms in the synonym table brings in
+// the single-word members of its group. Multi-word members are left out, as
+// their words would be searched one by one. The original terms are kept, so
+// the query only ever matches more. Phrases and excluded words are left as
+// written.
+func ExpandSearchQuery(query *TextQuery) {
+	synonymsOnce.Do(loadSynonyms)
+	expandSearchQuery(query, vocabularyWords(), termHasHits)
+}
+
+// expandSearchQuery is ExpandSearchQuery with the vocabulary of known words
+// and the check for terms the index finds as they are
+func expandSearchQuery(query *TextQuery, vocabulary map[string]int, hasHits func(term string) bool) {
+	words := make([]string, len(query.Terms))
+	for i, term := range query.Terms {
+		words[i] = strings.ToLower(term)
+		corrected := correctTerm(words[i], vocabulary)
+		if corrected != words[i] && !hasHits(words[i]) {
+			if query.Corrections == nil {
+				query.Corrections = map[string]string{}
+			}
+			query.Corrections[term] = corrected
+			words[i] = corrected
+		}
+	}
+
+	expanded := make([]string, 0, len(query.Terms))
+	seen := map[string]bool{}
+	add := func(word string) {
+		if !seen[word] && len(expanded) < maxExpandedSearchTerms {
+			seen[word] = true
+			expanded = append(expanded, word)
+		}
+	}
+	for _, term := range query.Terms {
+		add(strings.ToLower(term))
+	}
+	for _, word := range words {
+		add(word)
+	}
+
+	for i := 0; i < len(words); {
+		size := matchSynonym(words[i:])
+		if size == 0 {
+			i++
+			continue
+		}
+		for _, synonym := range synonymGroups[strings.Join(words[i:i+size], " ")] {
+			if !strings.Contains(synonym, " ") {
+				add(synonym)
+			}
+		}
+		i += size
+	}
+
+	query.Terms = expanded
+}
+
+// matchSynonym returns how many of the leading words form the longest
+// synonym in the table, 0 if none does
+func matchSynonym(words []string) int {
+	for size := min(maxSynonymSize, len(words)); size > 0; size-- {
+		if _, ok := synonymGroups[strings.Join(words[:size], " ")]; ok {
+			return size
+		}
+	}
+	return 0
+}
+
+// correctTerm returns the known word closest to term, or term itself when it
+// is known or nothing is close enough. Short words are never corrected, up
+// to one typo is allowed in words of 4 to 7 letters and two in longer ones.
+// Ties go to the word used by the most properties.
+func correctTerm(term string, vocabulary map[string]int) string {
+	if _, ok := vocabulary[term]; ok {
+		return term
+	}
+
+	allowed := 0
+	switch length := utf8.RuneCountInString(term); {
+	case length >= 8:
+		allowed = 2
+	case length >= 4:
+		allowed = 1
+	}
+	if allowed == 0 {
+		return term
+	}
+
+	best, bestDistance, bestCount := term, allowed+1, -1
+	for word, count := range vocabulary {
+		distance := editDistance(term, word, allowed)
+		if distance < bestDistance ||
+			(distance == bestDistance && (count > bestCount || (count == bestCount && word < best))) {
+			best, bestDistance, bestCount = word, distance, count
+		}
+	}
+	if bestDistance > allowed {
+		return term
+	}
+	return best
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b: the insertions, deletions, substitutions and swaps of adjacent letters
+// turning one into the other. Anything above limit is reported as limit+1.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if abs(len(ra)-len(rb)) > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return min(prev[len(rb)], limit+1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// useTestSynonyms loads the synonym groups from csv in place of data/synonyms.csv
+func useTestSynonyms(t *testing.T, csv string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "synonyms.csv")
+	if err := os.WriteFile(path, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	synonymsOnce.Do(func() {})
+	synonymGroups, maxSynonymSize = map[string][]string{}, 0
+	if err := readSynonyms(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpandSearchQuery(t *testing.T) {
+	useTestSynonyms(t, "terms\n"+
+		"apartment,flat,apt\n"+
+		"pool,swimming pool\n"+
+		"gym,fitness center,fitness centre\n"+
+		"parking,car park,garage\n"+
+		"new delhi,delhi\n")
+
+	vocabulary := map[string]int{
+		"apartment": 10, "bangalore": 5, "villa": 3, "sunny": 2, "pool": 4,
+	}
+	hits := map[string]bool{"apartmant": true}
+	hasHits := func(term string) bool { return hits[term] }
+
+	tests := []struct {
+		terms           []string
+		wantTerms       []string
+		wantCorrections map[string]string
+	}{
+		{[]string{"flat"}, []string{"flat", "apartment", "apt"}, nil},
+		{[]string{"Villa"}, []string{"villa"}, nil},
+		// Multi-word members are never split into words
+		{[]string{"delhi"}, []string{"delhi"}, nil},
+		{[]string{"gym"}, []string{"gym"}, nil},
+		{[]string{"parking"}, []string{"parking", "garage"}, nil},
+		// Runs of words are matched against multi-word members
+		{[]string{"swimming", "pool"}, []string{"swimming", "pool"}, nil},
+		{[]string{"fitness", "centre"}, []string{"fitness", "centre", "gym"}, nil},
+		{[]string{"new", "delhi"}, []string{"new", "delhi"}, nil},
+		// Typos are corrected, then expanded
+		{[]string{"banglore"}, []string{"banglore", "bangalore"}, map[string]string{"banglore": "bangalore"}},
+		{[]string{"apartmnt"}, []string{"apartmnt", "apartment", "flat", "apt"}, map[string]string{"apartmnt": "apartment"}},
+		{[]string{"vila"}, []string{"vila", "villa"}, map[string]string{"vila": "villa"}},
+		// Terms with hits of their own are not typos
+		{[]string{"apartmant"}, []string{"apartmant"}, nil},
+		// Short words are never corrected
+		{[]string{"poo"}, []string{"poo"}, nil},
+	}
+
+	for _, tt := range tests {
+		query := &TextQuery{Terms: tt.terms}
+		expandSearchQuery(query, vocabulary, hasHits)
+		if !reflect.DeepEqual(query.Terms, tt.wantTerms) {
+			t.Errorf("expandSearchQuery(%v) terms = %v, want %v", tt.terms, query.Terms, tt.wantTerms)
+		}
+		if !reflect.DeepEqual(query.Corrections, tt.wantCorrections) {
+			t.Errorf("expandSearchQuery(%v) corrections = %v, want %v", tt.terms, query.Corrections, tt.wantCorrections)
+		}
+	}
+}
+
+func TestExpandSearchQueryKeepsPhrasesAndExclusions(t *testing.T) {
+	useTestSynonyms(t, "terms\napartment,flat\n")
+
+	query := &TextQuery{Terms: []string{"flat"}, Phrases: []string{"sea view"}, Excluded: []string{"flat"}}
+	expandSearchQuery(query, map[string]int{}, func(string) bool { return false })
+
+	if want := []string{"sea view"}; !reflect.DeepEqual(query.Phrases, want) {
+		t.Errorf("phrases = %v, want %v", query.Phrases, want)
+	}
+	if want := []string{"flat"}; !reflect.DeepEqual(query.Excluded, want) {
+		t.Errorf("excluded = %v, want %v", query.Excluded, want)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"villa", "villa", 2, 0},
+		{"vila", "villa", 2, 1},
+		{"apartmnet", "apartment", 2, 1}, // adjacent swap
+		{"banglore", "bangalore", 2, 1},
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 1, 2},
+		{"a", "abcdef", 2, 3},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.limit); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Upper bounds on what a single search query may contain
+	maxSearchQueryLength = 200
+	maxSearchTerms       = 20
+)
+
+var (
+	ErrEmptySearchQuery   = errors.New("search query must contain at least one word or phrase to match")
+	ErrSearchQueryTooLong = errors.New("search query is too long")
+)
+
+// TextQuery is a parsed search box query. Words are matched individually,
+// every phrase has to appear as written and excluded words must not appear.
+// Corrections records the misspelled terms ExpandSearchQuery fixed.
+type TextQuery struct {
+	Terms       []string
+	Phrases     []string
+	Excluded    []string
+	Corrections map[string]string
+}
+
+// ParseSearchQuery parses user input into a TextQuery. Double quotes mark a
+// phrase and a leading "-" excludes a word. Outside of phrases only letters
+// and digits are kept, so nothing the user types is interpreted as an operator.
+func ParseSearchQuery(input string) (*TextQuery, error) {
+	if len(input) > maxSearchQueryLength {
+		return nil, ErrSearchQueryTooLong
+	}
+
+	query := &TextQuery{}
+	rest := input
+	for rest != "" {
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+		if rest == "" {
+			break
+		}
+
+		// Quoted phrase, an unterminated quote runs to the end of the input
+		if rest[0] == '"' {
+			phrase := rest[1:]
+			rest = ""
+			if end := strings.IndexByte(phrase, '"'); end >= 0 {
+				phrase, rest = phrase[:end], phrase[end+1:]
+			}
+			// Phrases are matched as written, only whitespace is normalized
+			phrase = strings.Join(strings.Fields(strings.ReplaceAll(phrase, `\`, " ")), " ")
+			if len(searchWords(phrase)) > 0 {
+				query.Phrases = append(query.Phrases, phrase)
+			}
+			continue
+		}
+
+		// Next whitespace delimited token
+		token := rest
+		rest = ""
+		if end := strings.IndexFunc(token, unicode.IsSpace); end >= 0 {
+			token, rest = token[:end], token[end:]
+		}
+
+		if strings.HasPrefix(token, "-") {
+			query.Excluded = append(query.Excluded, searchWords(token[1:])...)
+		} else {
+			query.Terms = append(query.Terms, searchWords(token)...)
+		}
+	}
+
+	if len(query.Terms) == 0 && len(query.Phrases) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+	if len(query.Terms)+len(query.Phrases)+len(query.Excluded) > maxSearchTerms {
+		return nil, ErrSearchQueryTooLong
+	}
+
+	return query, nil
+}
+
+// searchWords splits text into words made of letters and digits. Everything
+// else, including quotes and hyphens, acts as a separator.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// MongoSearch renders the query in the syntax of MongoDB's $text operator
+func (q *TextQuery) MongoSearch() string {
+	parts := make([]string, 0, len(q.Terms)+len(q.Phrases)+len(q.Excluded))
+	parts = append(parts, q.Terms...)
+	for _, phrase := range q.Phrases {
+		parts = append(parts, `"`+phrase+`"`)
+	}
+	for _, word := range q.Excluded {
+		parts = append(parts, "-"+word)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		input    string
+		terms    []string
+		phrases  []string
+		excluded []string
+		search   string
+	}{
+		{"villa pune", []string{"villa", "pune"}, nil, nil, "villa pune"},
+		{`  sea   view  `, []string{"sea", "view"}, nil, nil, "sea view"},
+		{`"sea view" villa`, []string{"villa"}, []string{"sea view"}, nil, `villa "sea view"`},
+		{`"sea   view"`, nil, []string{"sea view"}, nil, `"sea view"`},
+		{`villa "sea view`, []string{"villa"}, []string{"sea view"}, nil, `villa "sea view"`},
+		{"villa -studio", []string{"villa"}, nil, []string{"studio"}, "villa -studio"},
+		{"2-bhk flat", []string{"2", "bhk", "flat"}, nil, nil, "2 bhk flat"},
+		// Operators and punctuation outside of phrases are dropped
+		{`villa$ {"$where": 1}`, []string{"villa", "where", "1"}, nil, nil, "villa where 1"},
+		{`"back\"slash"`, []string{"slash"}, []string{"back"}, nil, `slash "back"`},
+		{"Ünïcode Bengaluru", []string{"Ünïcode", "Bengaluru"}, nil, nil, "Ünïcode Bengaluru"},
+	}
+
+	for _, tt := range tests {
+		query, err := ParseSearchQuery(tt.input)
+		if err != nil {
+			t.Errorf("ParseSearchQuery(%q): %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(query.Terms, tt.terms) {
+			t.Errorf("ParseSearchQuery(%q) terms = %q, want %q", tt.input, query.Terms, tt.terms)
+		}
+		if !reflect.DeepEqual(query.Phrases, tt.phrases) {
+			t.Errorf("ParseSearchQuery(%q) phrases = %q, want %q", tt.input, query.Phrases, tt.phrases)
+		}
+		if !reflect.DeepEqual(query.Excluded, tt.excluded) {
+			t.Errorf("ParseSearchQuery(%q) excluded = %q, want %q", tt.input, query.Excluded, tt.excluded)
+		}
+		if search := query.MongoSearch(); search != tt.search {
+			t.Errorf("ParseSearchQuery(%q).MongoSearch() = %q, want %q", tt.input, search, tt.search)
+		}
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"", ErrEmptySearchQuery},
+		{"   ", ErrEmptySearchQuery},
+		{`"" -villa`, ErrEmptySearchQuery},
+		{"$%^ --", ErrEmptySearchQuery},
+		{strings.Repeat("a", maxSearchQueryLength+1), ErrSearchQueryTooLong},
+		{strings.Repeat("a ", maxSearchTerms+1), ErrSearchQueryTooLong},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseSearchQuery(tt.input); err != tt.want {
+			t.Errorf("ParseSearchQuery(%q) error = %v, want %v", tt.input, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	DefaultSimilarLimit = 10
+	MaxSimilarLimit     = 50
+)
+
+// Candidates are first ranked in MongoDB by a rough score, only the best
+// maxScoredCandidates of them are loaded and scored exactly
+const maxScoredCandidates = 500
+
+// Weights of the similarity components, they add up to 1
+const (
+	similarityTypeWeight      = 0.20
+	similarityCityWeight      = 0.20
+	similarityStateWeight     = 0.10 // same state but another city
+	similarityPriceWeight     = 0.15
+	similarityAreaWeight      = 0.10
+	similarityBedroomsWeight  = 0.10
+	similarityAmenitiesWeight = 0.15
+	similarityTagsWeight      = 0.10
+)
+
+// SimilarProperty is a property with how similar it is to another one,
+// between 0 and 1, and the reasons why
+type SimilarProperty struct {
+	models.Property
+
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+// FindSimilarProperties returns the properties most similar to property.
+// Only properties with the same listing type (rent or sale) are considered,
+// and only those sharing the type, city or price range with it. Of those, the
+// ones closest by type, place, price and bedrooms are scored.
+func FindSimilarProperties(property *models.Property, limit int) ([]SimilarProperty, error) {
+	filter := bson.M{
+		"id":          bson.M{"$ne": property.ID},
+		"listingType": property.ListingType,
+		"$or": bson.A{
+			bson.M{"type": property.Type},
+			bson.M{"city": property.City},
+			bson.M{"price": bson.M{"$gte": property.Price / 2, "$lte": property.Price * 2}},
+		},
+	}
+
+	priceRange := bson.M{"$and": bson.A{
+		bson.M{"$gte": bson.A{"$price", property.Price / 2}},
+		bson.M{"$lte": bson.A{"$price", property.Price * 2}},
+	}}
+	candidates, err := topCandidates(filter, bson.A{
+		weightIf(bson.M{"$eq": bson.A{"$type", property.Type}}, similarityTypeWeight),
+		weightIf(bson.M{"$eq": bson.A{"$city", property.City}}, similarityCityWeight),
+		weightIf(bson.M{"$and": bson.A{
+			bson.M{"$ne": bson.A{"$city", property.City}},
+			bson.M{"$eq": bson.A{"$state", property.State}},
+		}}, similarityStateWeight),
+		weightIf(priceRange, similarityPriceWeight),
+		weightIf(bson.M{"$eq": bson.A{"$bedrooms", property.Bedrooms}}, similarityBedroomsWeight),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	similar := make([]SimilarProperty, 0, len(candidates))
+	for _, candidate := range candidates {
+		score, reasons := PropertySimilarity(property, &candidate)
+		similar = append(similar, SimilarProperty{Property: candidate, Score: score, Reasons: reasons})
+	}
+
+	sort.SliceStable(similar, func(i, j int) bool {
+		if similar[i].Score != similar[j].Score {
+			return similar[i].Score > similar[j].Score
+		}
+		return similar[i].ID < similar[j].ID
+	})
+	if len(similar) > limit {
+		similar = similar[:limit]
+	}
+	return similar, nil
+}
+
+// topCandidates loads the maxScoredCandidates properties matching filter
+// with the highest prescore, the sum of the given MongoDB expressions
+func topCandidates(filter bson.M, prescore bson.A) ([]models.Property, error) {
+	pipeline := bson.A{
+		bson.M{"$match": filter},
+		bson.M{"$addFields": bson.M{"prescore": bson.M{"$add": prescore}}},
+		bson.M{"$sort": bson.D{{Key: "prescore", Value: -1}, {Key: "rating", Value: -1}, {Key: "id", Value: 1}}},
+		bson.M{"$limit": maxScoredCandidates},
+		bson.M{"$project": bson.M{"prescore": 0}},
+	}
+
+	cursor, err := mgm.Coll(&models.Property{}).Aggregate(mgm.Ctx(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []models.Property
+	if err := cursor.All(mgm.Ctx(), &candidates); err != nil {
+		return nil, err
+	}
+	return candidates, nil
+}
+
+// weightIf is a prescore expression worth weight when condition holds
+func weightIf(condition interface{}, weight float64) bson.M {
+	return bson.M{"$cond": bson.A{condition, weight, 0}}
+}
+
+// PropertySimilarity scores how similar b is to a, from 0 to 1
+func PropertySimilarity(a, b *models.Property) (float64, []string) {
+	score := 0.0
+	reasons := []string{}
+
+	if strings.EqualFold(a.Type, b.Type) {
+		score += similarityTypeWeight
+		reasons = append(reasons, fmt.Sprintf("Same type (%s)", b.Type))
+	}
+
+	if strings.EqualFold(a.City, b.City) && strings.EqualFold(a.State, b.State) {
+		score += similarityCityWeight
+		reasons = append(reasons, fmt.Sprintf("Same city (%s)", b.City))
+	} else if strings.EqualFold(a.State, b.State) {
+		score += similarityStateWeight
+		reasons = append(reasons, fmt.Sprintf("Same state (%s)", b.State))
+	}
+
+	if closeness := ratioCloseness(float64(a.Price), float64(b.Price)); closeness > 0 {
+		score += similarityPriceWeight * closeness
+		if closeness >= 0.5 {
+			reasons = append(reasons, fmt.Sprintf("Similar price (%s)", percentDifference(a.Price, b.Price)))
+		}
+	}
+
+	if closeness := ratioCloseness(float64(a.AreaSqFt), float64(b.AreaSqFt)); closeness > 0 {
+		score += similarityAreaWeight * closeness
+		if closeness >= 0.5 {
+			reasons = append(reasons, fmt.Sprintf("Similar area (%s)", percentDifference(a.AreaSqFt, b.AreaSqFt)))
+		}
+	}
+
+	bedroomDiff := math.Abs(float64(a.Bedrooms - b.Bedrooms))
+	if bedroomDiff < 3 {
+		score += similarityBedroomsWeight * (1 - bedroomDiff/3)
+		if bedroomDiff == 0 {
+			reasons = append(reasons, fmt.Sprintf("Same number of bedrooms (%d)", b.Bedrooms))
+		}
+	}
+
+	if similarity, shared := Jaccard(a.Amenities, b.Amenities); similarity > 0 {
+		score += similarityAmenitiesWeight * similarity
+		reasons = append(reasons, "Shared amenities: "+strings.Join(shared, ", "))
+	}
+
+	if similarity, shared := Jaccard(a.Tags, b.Tags); similarity > 0 {
+		score += similarityTagsWeight * similarity
+		reasons = append(reasons, "Shared tags: "+strings.Join(shared, ", "))
+	}
+
+	return math.Round(score*1000) / 1000, reasons
+}
+
+// Jaccard returns the Jaccard similarity of two sets of strings, compared
+// case-insensitively, and the values they share
+func Jaccard(a, b []string) (float64, []string) {
+	setA, setB := normalizeSet(a), normalizeSet(b)
+	if len(setA) == 0 && len(setB) == 0 {
+		return 0, nil
+	}
+
+	inA := map[string]bool{}
+	for _, value := range setA {
+		inA[value] = true
+	}
+
+	shared := []string{}
+	for _, value := range setB {
+		if inA[value] {
+			shared = append(shared, value)
+		}
+	}
+
+	union := len(setA) + len(setB) - len(shared)
+	return float64(len(shared)) / float64(union), shared
+}
+
+// ratioCloseness is 1 for equal values, falling to 0 once one value is
+// twice the other
+func ratioCloseness(a, b float64) float64 {
+	if a <= 0 || b <= 0 {
+		return 0
+	}
+	return math.Max(0, 1-math.Abs(math.Log2(a/b)))
+}
+
+func percentDifference(base, other int) string {
+	if base == 0 {
+		return "n/a"
+	}
+	diff := math.Round(float64(other-base) / float64(base) * 100)
+	if diff == 0 {
+		return "same"
+	}
+	return fmt.Sprintf("%+.0f%%", diff)
+}
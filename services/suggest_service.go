@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	DefaultSuggestLimit = 10
+	MaxSuggestLimit     = 50
+	MaxSuggestPrefix    = 100
+)
+
+// SuggestFields are the property fields the autocomplete dictionary is built
+// from, and whether each of them holds a list
+var SuggestFields = map[string]bool{
+	"city":      false,
+	"state":     false,
+	"type":      false,
+	"amenities": true,
+	"tags":      true,
+}
+
+var ErrUnknownSuggestField = errors.New("unknown suggestion field")
+
+// Suggest returns the dictionary entries with a word starting with prefix,
+// compared case-insensitively. Values starting with the prefix rank before
+// values where only a later word does, then the most common values come
+// first. Only the given fields are searched, or all of them if none is given.
+func Suggest(prefix string, fields []string, limit int) ([]models.Suggestion, error) {
+	normalized := strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+
+	filter := bson.M{
+		"normalized": bson.M{"$regex": `(^|[\s-])` + regexp.QuoteMeta(normalized)},
+	}
+	if len(fields) > 0 {
+		for _, field := range fields {
+			if _, ok := SuggestFields[field]; !ok {
+				return nil, ErrUnknownSuggestField
+			}
+		}
+		filter["field"] = bson.M{"$in": fields}
+	}
+
+	var suggestions []models.Suggestion
+	if err := mgm.Coll(&models.Suggestion{}).SimpleFind(&suggestions, filter); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		aPrefix := strings.HasPrefix(a.Normalized, normalized)
+		bPrefix := strings.HasPrefix(b.Normalized, normalized)
+		if aPrefix != bPrefix {
+			return aPrefix
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Normalized != b.Normalized {
+			return a.Normalized < b.Normalized
+		}
+		return a.Field < b.Field
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// RebuildSuggestions recomputes the autocomplete dictionary from the
+// properties. The new dictionary replaces the old one in a single step, so
+// readers never see it half built.
+func RebuildSuggestions() error {
+	properties := mgm.Coll(&models.Property{}).Name()
+
+	// One sub-pipeline per field, unioned together. Values are grouped
+	// case-insensitively and the most common spelling is kept.
+	var pipeline bson.A
+	first := true
+	for _, field := range sortedSuggestFields() {
+		stages := suggestFieldPipeline(field, SuggestFields[field])
+		if first {
+			pipeline = append(pipeline, stages...)
+			first = false
+			continue
+		}
+		pipeline = append(pipeline, bson.M{"$unionWith": bson.M{"coll": properties, "pipeline": stages}})
+	}
+	pipeline = append(pipeline, bson.M{"$out": mgm.Coll(&models.Suggestion{}).Name()})
+
+	cursor, err := mgm.Coll(&models.Property{}).Aggregate(mgm.Ctx(), pipeline)
+	if err != nil {
+		return err
+	}
+	if err := cursor.Close(mgm.Ctx()); err != nil {
+		return err
+	}
+
+	// Typos are corrected to words of the dictionary
+	InvalidateSearchVocabulary()
+	return nil
+}
+
+func suggestFieldPipeline(field string, isList bool) bson.A {
+	stages := bson.A{bson.M{"$project": bson.M{"_id": 0, "value": "$" + field}}}
+	if isList {
+		stages = append(stages, bson.M{"$unwind": "$value"})
+	}
+	return append(stages,
+		bson.M{"$match": bson.M{"value": bson.M{"$type": "string"}}},
+		bson.M{"$project": bson.M{"value": bson.M{"$trim": bson.M{"input": "$value"}}}},
+		bson.M{"$match": bson.M{"value": bson.M{"$ne": ""}}},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"normalized": bson.M{"$toLower": "$value"}, "value": "$value"},
+			"count": bson.M{"$sum": 1},
+		}},
+		bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id.value", Value: 1}}},
+		bson.M{"$group": bson.M{
+			"_id":   "$_id.normalized",
+			"value": bson.M{"$first": "$_id.value"},
+			"count": bson.M{"$sum": "$count"},
+		}},
+		bson.M{"$project": bson.M{
+			"_id":        0,
+			"field":      bson.M{"$literal": field},
+			"value":      1,
+			"normalized": "$_id",
+			"count":      1,
+		}},
+	)
+}
+
+func sortedSuggestFields() []string {
+	fields := make([]string, 0, len(SuggestFields))
+	for field := range SuggestFields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+var suggestRefresh struct {
+	sync.Mutex
+	running bool
+	pending bool
+}
+
+// RefreshSuggestions rebuilds the autocomplete dictionary after properties
+// changed. It is meant to run in the background: refreshes requested while
+// one is running are folded into a single rebuild once it finishes.
+func RefreshSuggestions() {
+	suggestRefresh.Lock()
+	if suggestRefresh.running {
+		suggestRefresh.pending = true
+		suggestRefresh.Unlock()
+		return
+	}
+	suggestRefresh.running = true
+	suggestRefresh.Unlock()
+
+	for {
+		if err := RebuildSuggestions(); err != nil {
+			log.Printf("Failed to rebuild search suggestions: %v", err)
+		}
+
+		suggestRefresh.Lock()
+		if !suggestRefresh.pending {
+			suggestRefresh.running = false
+			suggestRefresh.Unlock()
+			return
+		}
+		suggestRefresh.pending = false
+		suggestRefresh.Unlock()
+	}
+}
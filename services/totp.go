@@ -0,0 +1,139 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"property_lister/config"
+)
+
+// RFC 6238 parameters, matching the defaults of common authenticator apps
+const (
+	TOTPPeriod = 30
+	TOTPDigits = 6
+	// Number of periods before and after the current one that are accepted
+	TOTPSkew = 1
+
+	RecoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a new random base32 encoded 160-bit secret
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPProvisioningURI returns the otpauth:// URI used to enrol the secret in
+// an authenticator app, usually rendered as a QR code
+func TOTPProvisioningURI(secret, accountName string) string {
+	issuer := os.Getenv("TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Property Lister"
+	}
+
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(TOTPDigits))
+	params.Set("period", fmt.Sprint(TOTPPeriod))
+
+	label := url.PathEscape(issuer + ":" + accountName)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+// totpCode computes the HOTP value (RFC 4226) for the given counter
+func totpCode(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := (uint32(sum[offset])&0x7f)<<24 |
+		uint32(sum[offset+1])<<16 |
+		uint32(sum[offset+2])<<8 |
+		uint32(sum[offset+3])
+
+	return fmt.Sprintf("%0*d", TOTPDigits, value%uint32(math.Pow10(TOTPDigits)))
+}
+
+// ValidateTOTP checks a code against the secret at time t, allowing for
+// clock skew. It returns the matched time step so callers can reject replays.
+func ValidateTOTP(secret, code string, t time.Time) (int64, bool) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != TOTPDigits {
+		return 0, false
+	}
+
+	current := t.Unix() / TOTPPeriod
+	for step := current - TOTPSkew; step <= current+TOTPSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// MarkTOTPStepUsed records that the user consumed the code of a time step.
+// It returns false if the step was already used, i.e. the code is replayed.
+func MarkTOTPStepUsed(userID string, step int64) bool {
+	key := fmt.Sprintf("totp_used:%s:%d", userID, step)
+	ttl := time.Duration(TOTPPeriod*(2*TOTPSkew+1)) * time.Second
+
+	ok, err := config.RedisClient.SetNX(config.Ctx, key, "used", ttl).Result()
+	if err != nil {
+		return false
+	}
+	return ok
+}
+
+// GenerateRecoveryCodes returns new single-use recovery codes together with
+// the hashes that get stored
+func GenerateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(b)
+		code := raw[:5] + "-" + raw[5:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode normalizes and hashes a recovery code for storage/lookup
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	return hashToken(normalized)
+}
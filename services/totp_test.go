@@ -0,0 +1,69 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret of the RFC 6238 test vectors, "12345678901234567890" in base32
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		at       int64
+		wantStep int64
+		wantOK   bool
+	}{
+		// Last six digits of the SHA1 vectors of RFC 6238, appendix B
+		{"rfc vector 59", rfcTOTPSecret, "287082", 59, 1, true},
+		{"rfc vector 1111111109", rfcTOTPSecret, "081804", 1111111109, 37037036, true},
+		{"rfc vector 1234567890", rfcTOTPSecret, "005924", 1234567890, 41152263, true},
+		{"previous period", rfcTOTPSecret, "287082", 59 + TOTPPeriod, 1, true},
+		{"next period", rfcTOTPSecret, "081804", 1111111109 - TOTPPeriod, 37037036, true},
+		{"outside of skew", rfcTOTPSecret, "287082", 59 + 2*TOTPPeriod, 0, false},
+		{"lower case secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "287082", 59, 1, true},
+		{"code with spaces", rfcTOTPSecret, " 287 082 ", 59, 1, true},
+		{"wrong code", rfcTOTPSecret, "287083", 59, 0, false},
+		{"short code", rfcTOTPSecret, "28708", 59, 0, false},
+		{"invalid secret", "not base32!", "287082", 59, 0, false},
+	}
+
+	for _, tt := range tests {
+		step, ok := ValidateTOTP(tt.secret, tt.code, time.Unix(tt.at, 0))
+		if step != tt.wantStep || ok != tt.wantOK {
+			t.Errorf("%s: ValidateTOTP = (%d, %v), want (%d, %v)", tt.name, step, ok, tt.wantStep, tt.wantOK)
+		}
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := totpEncoding.DecodeString(secret)
+	if err != nil {
+		t.Fatalf("secret %q is not base32: %v", secret, err)
+	}
+	if len(key) != 20 {
+		t.Errorf("secret has %d bytes, want 20", len(key))
+	}
+
+	code := totpCode(key, time.Now().Unix()/TOTPPeriod)
+	if _, ok := ValidateTOTP(secret, code, time.Now()); !ok {
+		t.Errorf("code %s of a generated secret was rejected", code)
+	}
+}
+
+func TestHashRecoveryCode(t *testing.T) {
+	if HashRecoveryCode(" AB12C-3DE45 ") != HashRecoveryCode("ab12c-3de45") {
+		t.Error("recovery codes differing in case and spacing hash differently")
+	}
+	if HashRecoveryCode("ab12c-3de45") == HashRecoveryCode("ab12c-3de46") {
+		t.Error("different recovery codes hash the same")
+	}
+}
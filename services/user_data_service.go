@@ -0,0 +1,274 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"log"
+	"time"
+
+	"property_lister/models"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UserDataExport bundles everything stored about a user
+type UserDataExport struct {
+	ExportedAt              time.Time               `json:"exported_at"`
+	Profile                 *models.User            `json:"profile"`
+	Listings                []models.Property       `json:"listings"`
+	FavoriteProperties      []models.Property       `json:"favorite_properties"`
+	RecommendationsSent     []models.Recommendation `json:"recommendations_sent"`
+	RecommendationsReceived []models.Recommendation `json:"recommendations_received"`
+	APIKeys                 []models.APIKey         `json:"api_keys"`
+	SavedSearches           []models.SavedSearch    `json:"saved_searches"`
+	SearchAlerts            []models.SearchAlert    `json:"search_alerts"`
+}
+
+// BuildUserExport collects all data tied to the user across collections
+func BuildUserExport(user *models.User) (*UserDataExport, error) {
+	userID := user.ID.Hex()
+	export := &UserDataExport{
+		ExportedAt:              time.Now(),
+		Profile:                 user,
+		Listings:                []models.Property{},
+		FavoriteProperties:      []models.Property{},
+		RecommendationsSent:     []models.Recommendation{},
+		RecommendationsReceived: []models.Recommendation{},
+		APIKeys:                 []models.APIKey{},
+		SavedSearches:           []models.SavedSearch{},
+		SearchAlerts:            []models.SearchAlert{},
+	}
+
+	cursor, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), bson.M{
+		"created_by": userID,
+	}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, err
+	}
+	if err = cursor.All(mgm.Ctx(), &export.Listings); err != nil {
+		return nil, err
+	}
+
+	if len(user.Favorites) > 0 {
+		cursor, err := mgm.Coll(&models.Property{}).Find(mgm.Ctx(), bson.M{
+			"id": bson.M{"$in": user.Favorites},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = cursor.All(mgm.Ctx(), &export.FavoriteProperties); err != nil {
+			return nil, err
+		}
+	}
+
+	err = mgm.Coll(&models.Recommendation{}).SimpleFind(&export.RecommendationsSent, bson.M{
+		"sender_id": user.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = mgm.Coll(&models.Recommendation{}).SimpleFind(&export.RecommendationsReceived, bson.M{
+		"recipient_email": user.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = mgm.Coll(&models.APIKey{}).SimpleFind(&export.APIKeys, bson.M{
+		"user_id": user.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = mgm.Coll(&models.SavedSearch{}).SimpleFind(&export.SavedSearches, bson.M{
+		"user_id": user.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = mgm.Coll(&models.SearchAlert{}).SimpleFind(&export.SearchAlerts, bson.M{
+		"user_id": user.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// WriteUserExportZip writes the export as a ZIP archive with one JSON file per section
+func WriteUserExportZip(w io.Writer, export *UserDataExport) error {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"listings.json", export.Listings},
+		{"favorite_properties.json", export.FavoriteProperties},
+		{"recommendations_sent.json", export.RecommendationsSent},
+		{"recommendations_received.json", export.RecommendationsReceived},
+		{"api_keys.json", export.APIKeys},
+		{"saved_searches.json", export.SavedSearches},
+		{"search_alerts.json", export.SearchAlerts},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, file := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(fw)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
+
+// EraseUser permanently removes the user together with their listings and
+// recommendations, and scrubs references to them from other accounts.
+// The user document is deleted last so a failed run can be retried.
+func EraseUser(user *models.User) error {
+	userID := user.ID.Hex()
+
+	// Listings created by the user, and other users' favorites pointing at them
+	var listingIDs []string
+	var listings []models.Property
+	err := mgm.Coll(&models.Property{}).SimpleFind(&listings, bson.M{"created_by": userID})
+	if err != nil {
+		return err
+	}
+	for _, listing := range listings {
+		listingIDs = append(listingIDs, listing.ID)
+	}
+
+	var affectedFavoriteUsers []models.User
+	if len(listingIDs) > 0 {
+		err = mgm.Coll(&models.User{}).SimpleFind(&affectedFavoriteUsers, bson.M{
+			"_id":       bson.M{"$ne": user.ID},
+			"favorites": bson.M{"$in": listingIDs},
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = mgm.Coll(&models.User{}).UpdateMany(mgm.Ctx(),
+			bson.M{"favorites": bson.M{"$in": listingIDs}},
+			bson.M{"$pull": bson.M{"favorites": bson.M{"$in": listingIDs}}},
+		)
+		if err != nil {
+			return err
+		}
+
+		if _, err = mgm.Coll(&models.Property{}).DeleteMany(mgm.Ctx(), bson.M{"created_by": userID}); err != nil {
+			return err
+		}
+		if err = DeleteSearchAlertsForProperties(listingIDs); err != nil {
+			return err
+		}
+		go RefreshSuggestions()
+	}
+
+	// Recommendations sent or received by the user, or about their listings
+	conditions := []bson.M{
+		{"sender_id": user.ID},
+		{"recipient_email": user.Email},
+	}
+	if len(listingIDs) > 0 {
+		conditions = append(conditions, bson.M{"property_id": bson.M{"$in": listingIDs}})
+	}
+	recommendationFilter := bson.M{"$or": conditions}
+
+	var recommendations []models.Recommendation
+	err = mgm.Coll(&models.Recommendation{}).SimpleFind(&recommendations, recommendationFilter)
+	if err != nil {
+		return err
+	}
+
+	var recommendationIDs []primitive.ObjectID
+	affectedSenders := map[string]bool{}
+	affectedRecipients := map[string]bool{}
+	for _, rec := range recommendations {
+		recommendationIDs = append(recommendationIDs, rec.ID)
+		if rec.SenderID != user.ID {
+			affectedSenders[rec.SenderID.Hex()] = true
+		}
+		if rec.RecipientEmail != user.Email {
+			affectedRecipients[rec.RecipientEmail] = true
+		}
+	}
+
+	if len(recommendationIDs) > 0 {
+		_, err = mgm.Coll(&models.User{}).UpdateMany(mgm.Ctx(), bson.M{}, bson.M{
+			"$pull": bson.M{
+				"recommendations_sent":     bson.M{"$in": recommendationIDs},
+				"recommendations_received": bson.M{"$in": recommendationIDs},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if _, err = mgm.Coll(&models.Recommendation{}).DeleteMany(mgm.Ctx(), recommendationFilter); err != nil {
+			return err
+		}
+	}
+
+	// Saved searches and the alerts they raised
+	if _, err = mgm.Coll(&models.SearchAlert{}).DeleteMany(mgm.Ctx(), bson.M{"user_id": user.ID}); err != nil {
+		return err
+	}
+	if _, err = mgm.Coll(&models.SavedSearch{}).DeleteMany(mgm.Ctx(), bson.M{"user_id": user.ID}); err != nil {
+		return err
+	}
+
+	// API keys would otherwise keep authenticating as a user that no longer exists
+	if _, err = mgm.Coll(&models.APIKey{}).DeleteMany(mgm.Ctx(), bson.M{"user_id": user.ID}); err != nil {
+		return err
+	}
+
+	// Finally the account itself
+	if _, err = mgm.Coll(user).DeleteOne(mgm.Ctx(), bson.M{"_id": user.ID}); err != nil {
+		return err
+	}
+
+	if err := InvalidateUserCache(userID); err != nil {
+		log.Printf("Failed to invalidate cache for erased user %s: %v", userID, err)
+	}
+	if err := RevokeAllUserTokens(userID); err != nil {
+		log.Printf("Failed to revoke tokens for erased user %s: %v", userID, err)
+	}
+	// The login guard keeps its failure history under the email address
+	if err := UnlockAccount(user.Email); err != nil {
+		log.Printf("Failed to clear login guard for erased user %s: %v", userID, err)
+	}
+
+	// Refresh the caches of everyone whose data changed
+	for _, affected := range affectedFavoriteUsers {
+		go UpdateFavoritesCache(affected.ID.Hex())
+	}
+	for senderID := range affectedSenders {
+		go UpdateSentRecommendationsCache(senderID)
+	}
+	for email := range affectedRecipients {
+		go UpdateReceivedRecommendationsCacheByEmail(email)
+	}
+
+	log.Printf("Erased user %s: %d listings, %d recommendations", userID, len(listingIDs), len(recommendationIDs))
+	return nil
+}
@@ -1,9 +1,20 @@
 package types
 
-// PaginationMeta contains pagination information for API responses
+// PaginationMeta contains pagination information for API responses.
+// Cursor based requests have no page number, they continue from NextCursor
+// or PrevCursor instead, and only have totals when asked for them
 type PaginationMeta struct {
-	Page       int   `json:"page"`
-	Limit      int   `json:"limit"`
-	Total      int64 `json:"total"`
-	TotalPages int   `json:"total_pages"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	Total      *int64 `json:"total,omitempty"`
+	TotalPages *int   `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// PageTotals returns the Total and TotalPages of total items split in pages
+// of limit items
+func PageTotals(total int64, limit int) (*int64, *int) {
+	totalPages := int((total + int64(limit) - 1) / int64(limit))
+	return &total, &totalPages
 }